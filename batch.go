@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// changedFilesCtxKey carries the files that triggered a job
+type changedFilesCtxKey struct{}
+
+// pendingBatch collects the files changed during a job window
+type pendingBatch struct {
+	files []string
+	timer *time.Timer
+}
+
+// batcher aggregates events per job and fires once per window
+type batcher struct {
+	mu       sync.Mutex
+	pending  map[string]*pendingBatch
+	lastFire map[string]time.Time
+}
+
+// newBatcher creates a new batcher
+func newBatcher() *batcher {
+	return &batcher{
+		pending:  make(map[string]*pendingBatch),
+		lastFire: make(map[string]time.Time),
+	}
+}
+
+// enqueue adds a file to the job window, opening one if needed
+func (v *Vai) enqueue(name string, job Job, path string) {
+	if v.batcher == nil {
+		v.batcher = newBatcher()
+	}
+	b := v.batcher
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if p, ok := b.pending[name]; ok {
+		if !slices.Contains(p.files, path) {
+			p.files = append(p.files, path)
+		}
+		return
+	}
+
+	// Wait for the batch window, or until the cooldown since the last run is over
+	delay := job.Trigger.Batch
+	if last, ok := b.lastFire[name]; ok {
+		if wait := time.Until(last.Add(job.Trigger.Cooldown)); wait > delay {
+			delay = wait
+		}
+	}
+
+	p := &pendingBatch{files: []string{path}}
+	b.pending[name] = p
+	logger.log(SeverityDebug, OpInfo, "Batching events for job '%s' for %s", name, delay.Round(time.Millisecond))
+
+	p.timer = time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		files := p.files
+		delete(b.pending, name)
+		b.lastFire[name] = time.Now()
+		b.mu.Unlock()
+
+		// Run the definition current when the window closes, a reload may have replaced it
+		current, ok := v.job(name)
+		if !ok {
+			logger.log(SeverityDebug, OpInfo, "Dropping batch of removed job '%s'", name)
+			return
+		}
+		v.trigger(name, current, files)
+	})
+}
+
+// cancel drops the pending batch of a job
+func (b *batcher) cancel(name string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if p, ok := b.pending[name]; ok {
+		p.timer.Stop()
+		delete(b.pending, name)
+	}
+}
+
+// trigger runs a job for the given changed files, replacing any previous run
+func (v *Vai) trigger(name string, job Job, files []string) {
+	logger.log(SeverityDebug, OpSuccess, "Triggering job: %s (%d files)", green("[", name, "]"), len(files))
+	if v.launch != nil {
+		v.launch(name, job, files)
+		return
+	}
+
+	go func() {
+		// Register the job
+		ctx, deregister := v.manager.register(name)
+		ctx = context.WithValue(ctx, changedFilesCtxKey{}, files)
+		if v.args != nil && v.args.DryRun {
+			ctx = context.WithValue(ctx, dryRunCtxKey{}, true)
+		}
+		job.Name = name
+
+		defer deregister() // Deregister on complete
+		job.start(ctx)
+	}()
+}
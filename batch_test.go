@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnqueue(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping executor tests on Windows due to shell command differences")
+	}
+	resetGlobals()
+
+	out := filepath.Join(t.TempDir(), "out")
+	job := Job{
+		Cmd:     "sh",
+		Params:  []string{"-c", "echo \"$VAI_CHANGED_FILES\" >> " + out},
+		Trigger: &Trigger{Batch: 100 * time.Millisecond, Cooldown: 300 * time.Millisecond},
+	}
+	v := &Vai{manager: newManager(), Jobs: map[string]Job{"assets": job}}
+
+	v.enqueue("assets", job, "a.css")
+	v.enqueue("assets", job, "b.css")
+	v.enqueue("assets", job, "a.css")
+
+	waitFor := func(lines int) []string {
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			data, _ := os.ReadFile(out)
+			if got := strings.Fields(string(data)); len(got) >= lines {
+				return got
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("Job did not run %d times", lines)
+		return nil
+	}
+
+	got := waitFor(1)
+	expected := "a.css" + string(os.PathListSeparator) + "b.css"
+	if len(got) != 1 || got[0] != expected {
+		t.Fatalf("Expected one run with %q, got %v", expected, got)
+	}
+
+	// A new event right after the run waits for the cooldown
+	start := time.Now()
+	v.enqueue("assets", job, "c.css")
+	got = waitFor(2)
+	if got[1] != "c.css" {
+		t.Errorf("Expected second run with c.css, got %v", got)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("Expected the cooldown to delay the second run, ran after %v", elapsed)
+	}
+}
+
+func TestEnqueue_CurrentDefinition(t *testing.T) {
+	launched := make(chan Job, 1)
+	old := Job{Cmd: "old", Trigger: &Trigger{Batch: 50 * time.Millisecond}}
+	v := &Vai{manager: newManager(), Jobs: map[string]Job{"app": old}}
+	v.launch = func(_ string, job Job, _ []string) {
+		launched <- job
+	}
+
+	// A reload replaces the job while its batch is pending
+	v.enqueue("app", old, "main.go")
+	v.jobsMu.Lock()
+	v.Jobs = map[string]Job{"app": {Cmd: "new", Trigger: &Trigger{Batch: 50 * time.Millisecond}}}
+	v.jobsMu.Unlock()
+
+	select {
+	case job := <-launched:
+		if job.Cmd != "new" {
+			t.Errorf("Expected the reloaded definition to run, got %s", job.Cmd)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Batch did not fire")
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// completionShells are the shells vai writes completion scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// fileFlags are the flags completed with file names
+var fileFlags = []string{"path", "config"}
+
+// runCompletion prints the completion script of a shell
+func runCompletion(shell string) int {
+	script, err := completionScript(shell)
+	if err != nil {
+		logger.log(SeverityError, OpError, "%v", err)
+		return 1
+	}
+	fmt.Print(script)
+	return 0
+}
+
+// runComplete prints the values completed dynamically, one per line
+func runComplete(args *Args) int {
+	switch args.Input {
+	case "jobs":
+		// A broken config completes nothing rather than printing errors in the prompt
+		v, err := fromFile(args.ConfigFile)
+		if err != nil {
+			return 0
+		}
+		for _, name := range slices.Sorted(maps.Keys(v.Jobs)) {
+			fmt.Println(name)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown completion '%s'\n", args.Input)
+		return 1
+	}
+	return 0
+}
+
+// completionScript returns the completion script of a shell
+func completionScript(shell string) (string, error) {
+	switch shell {
+	case "bash":
+		return bashCompletion(), nil
+	case "zsh":
+		return zshCompletion(), nil
+	case "fish":
+		return fishCompletion(), nil
+	case "powershell":
+		return powershellCompletion(), nil
+	case "":
+		return "", fmt.Errorf("missing shell, use vai completion %s", strings.Join(completionShells, "|"))
+	}
+	return "", fmt.Errorf("unknown shell '%s', use %s", shell, strings.Join(completionShells, ", "))
+}
+
+// visibleCommands returns the subcommands shown to users
+func visibleCommands() []string {
+	var names []string
+	for _, name := range slices.Sorted(maps.Keys(subcommands)) {
+		if !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// commandFlags returns the flags accepted by a command
+func commandFlags(command string) []cliFlag {
+	var flags []cliFlag
+	for _, f := range cliFlags {
+		if f.accepts(command) {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// flagWords returns the long and short forms of flags
+func flagWords(flags []cliFlag) []string {
+	var words []string
+	for _, f := range flags {
+		words = append(words, "--"+f.name)
+		if f.short != "" {
+			words = append(words, "-"+f.short)
+		}
+	}
+	return words
+}
+
+// valueFlagWords returns the forms of the flags followed by a value, either completed with files or not
+func valueFlagWords(files bool) []string {
+	var flags []cliFlag
+	for _, f := range cliFlags {
+		if f.value && slices.Contains(fileFlags, f.name) == files {
+			flags = append(flags, f)
+		}
+	}
+	return flagWords(flags)
+}
+
+// bashCompletion returns the bash completion script
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString("# bash completion for vai, load it with: source <(vai completion bash)\n\n")
+	b.WriteString("_vai() {\n")
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    local cmd=\"\" config=\"\" i\n")
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+	b.WriteString("            --)\n")
+	b.WriteString("                declare -F _command_offset >/dev/null && _command_offset $((i + 1))\n")
+	b.WriteString("                return ;;\n")
+	// bash splits --flag=value into three words
+	b.WriteString("            =) ((i++)) ;;\n")
+	b.WriteString("            --config)\n")
+	b.WriteString("                [[ ${COMP_WORDS[i+1]} == = ]] && ((i++))\n")
+	b.WriteString("                config=\"${COMP_WORDS[i+1]}\"\n")
+	b.WriteString("                ((i++)) ;;\n")
+	fmt.Fprintf(&b, "            %s)\n", strings.Join(valueFlagWords(false), "|"))
+	b.WriteString("                [[ ${COMP_WORDS[i+1]} != = ]] && ((i++)) ;;\n")
+	fmt.Fprintf(&b, "            %s)\n", strings.Join(slices.DeleteFunc(valueFlagWords(true), func(w string) bool { return w == "--config" }), "|"))
+	b.WriteString("                [[ ${COMP_WORDS[i+1]} != = ]] && ((i++)) ;;\n")
+	b.WriteString("            -*) ;;\n")
+	b.WriteString("            *) cmd=\"${COMP_WORDS[i]}\"; break ;;\n")
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n\n")
+
+	b.WriteString("    case \"$prev\" in\n")
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", strings.Join(valueFlagWords(true), "|"))
+	fmt.Fprintf(&b, "        %s) return ;;\n", strings.Join(valueFlagWords(false), "|"))
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    case \"$cmd\" in\n")
+	b.WriteString("        \"\")\n")
+	b.WriteString("            if [[ $cur == -* ]]; then\n")
+	fmt.Fprintf(&b, "                COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(flagWords(commandFlags(runMode)), " "))
+	b.WriteString("            else\n")
+	fmt.Fprintf(&b, "                COMPREPLY=($(compgen -W \"%s\" -- \"$cur\") $(compgen -c -- \"$cur\"))\n", strings.Join(visibleCommands(), " "))
+	b.WriteString("            fi ;;\n")
+	for _, name := range visibleCommands() {
+		fmt.Fprintf(&b, "        %s)\n", name)
+		b.WriteString("            if [[ $cur == -* ]]; then\n")
+		fmt.Fprintf(&b, "                COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(flagWords(commandFlags(name)), " "))
+		switch subcommands[name].arg {
+		case "file":
+			b.WriteString("            else\n")
+			b.WriteString("                COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		case "shell":
+			b.WriteString("            else\n")
+			fmt.Fprintf(&b, "                COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(completionShells, " "))
+		case "job":
+			b.WriteString("            else\n")
+			b.WriteString("                COMPREPLY=($(compgen -W \"$(vai ${config:+--config \"$config\"} __complete jobs 2>/dev/null)\" -- \"$cur\"))\n")
+		}
+		b.WriteString("            fi ;;\n")
+	}
+	// Anything else is the command vai runs
+	b.WriteString("        *) declare -F _command_offset >/dev/null && _command_offset $i ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("complete -o default -F _vai vai\n")
+	return b.String()
+}
+
+// zshCompletion returns the zsh completion script
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString("#compdef vai\n")
+	b.WriteString("# zsh completion for vai, load it with: source <(vai completion zsh)\n\n")
+	b.WriteString("_vai() {\n")
+	b.WriteString("    local cmd=\"\" config=\"\" i\n")
+	b.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	b.WriteString("        case ${words[i]} in\n")
+	b.WriteString("            --) shift $i words; (( CURRENT -= i )); _normal; return ;;\n")
+	b.WriteString("            --config) config=${words[i+1]}; ((i++)) ;;\n")
+	b.WriteString("            --config=*) config=${words[i]#--config=} ;;\n")
+	fmt.Fprintf(&b, "            %s) ((i++)) ;;\n", strings.Join(slices.DeleteFunc(append(valueFlagWords(false), valueFlagWords(true)...), func(w string) bool { return w == "--config" }), "|"))
+	b.WriteString("            -*) ;;\n")
+	b.WriteString("            *) cmd=${words[i]}; break ;;\n")
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n\n")
+
+	b.WriteString("    case ${words[CURRENT-1]} in\n")
+	fmt.Fprintf(&b, "        %s) _files; return ;;\n", strings.Join(valueFlagWords(true), "|"))
+	fmt.Fprintf(&b, "        %s) return ;;\n", strings.Join(valueFlagWords(false), "|"))
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    local -a flags commands\n")
+	b.WriteString("    case $cmd in\n")
+	b.WriteString("        \"\")\n")
+	b.WriteString("            if [[ $PREFIX == -* ]]; then\n")
+	fmt.Fprintf(&b, "                flags=(%s)\n", zshDescribed(commandFlags(runMode)))
+	b.WriteString("                _describe flag flags\n")
+	b.WriteString("            else\n")
+	var commands []string
+	for _, name := range visibleCommands() {
+		commands = append(commands, zshQuote(name+":"+subcommands[name].usage))
+	}
+	fmt.Fprintf(&b, "                commands=(%s)\n", strings.Join(commands, " "))
+	b.WriteString("                _describe command commands\n")
+	b.WriteString("                _command_names -e\n")
+	b.WriteString("            fi ;;\n")
+	for _, name := range visibleCommands() {
+		fmt.Fprintf(&b, "        %s)\n", name)
+		b.WriteString("            if [[ $PREFIX == -* ]]; then\n")
+		fmt.Fprintf(&b, "                flags=(%s)\n", zshDescribed(commandFlags(name)))
+		b.WriteString("                _describe flag flags\n")
+		switch subcommands[name].arg {
+		case "file":
+			b.WriteString("            else\n")
+			b.WriteString("                _files\n")
+		case "shell":
+			b.WriteString("            else\n")
+			fmt.Fprintf(&b, "                compadd -- %s\n", strings.Join(completionShells, " "))
+		case "job":
+			b.WriteString("            else\n")
+			b.WriteString("                compadd -- ${(f)\"$(vai ${config:+--config \"$config\"} __complete jobs 2>/dev/null)\"}\n")
+		}
+		b.WriteString("            fi ;;\n")
+	}
+	// Anything else is the command vai runs
+	b.WriteString("        *) shift $((i - 1)) words; (( CURRENT -= i - 1 )); _normal ;;\n")
+	b.WriteString("    esac\n")
+	b.WriteString("}\n\n")
+	b.WriteString("if [[ $funcstack[1] == _vai ]]; then\n")
+	b.WriteString("    _vai \"$@\"\n")
+	b.WriteString("else\n")
+	b.WriteString("    compdef _vai vai\n")
+	b.WriteString("fi\n")
+	return b.String()
+}
+
+// zshDescribed formats flags as name:description items
+func zshDescribed(flags []cliFlag) string {
+	var items []string
+	for _, f := range flags {
+		items = append(items, zshQuote("--"+f.name+":"+f.usage))
+		if f.short != "" {
+			items = append(items, zshQuote("-"+f.short+":"+f.usage))
+		}
+	}
+	return strings.Join(items, " ")
+}
+
+// zshQuote quotes a word for zsh and bash
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishCompletion returns the fish completion script
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString("# fish completion for vai, load it with: vai completion fish | source\n\n")
+	b.WriteString("function __vai_command\n")
+	b.WriteString("    set -l tokens (commandline -opc)\n")
+	b.WriteString("    set -e tokens[1]\n")
+	b.WriteString("    set -l skip 0\n")
+	b.WriteString("    for t in $tokens\n")
+	b.WriteString("        if test $skip -eq 1\n")
+	b.WriteString("            set skip 0\n")
+	b.WriteString("            continue\n")
+	b.WriteString("        end\n")
+	b.WriteString("        switch $t\n")
+	b.WriteString("            case --\n")
+	b.WriteString("                echo --\n")
+	b.WriteString("                return\n")
+	fmt.Fprintf(&b, "            case %s\n", strings.Join(append(valueFlagWords(false), valueFlagWords(true)...), " "))
+	b.WriteString("                set skip 1\n")
+	b.WriteString("            case '-*'\n")
+	b.WriteString("            case '*'\n")
+	b.WriteString("                echo $t\n")
+	b.WriteString("                return\n")
+	b.WriteString("        end\n")
+	b.WriteString("    end\n")
+	b.WriteString("end\n\n")
+
+	b.WriteString("function __vai_using\n")
+	b.WriteString("    set -l cmd (__vai_command)\n")
+	b.WriteString("    test \"$cmd\" = \"$argv[1]\"\n")
+	b.WriteString("end\n\n")
+
+	b.WriteString("function __vai_jobs\n")
+	b.WriteString("    set -l tokens (commandline -opc)\n")
+	b.WriteString("    set -l i (contains -i -- --config $tokens)\n")
+	b.WriteString("    if test -n \"$i\"\n")
+	b.WriteString("        vai --config $tokens[(math $i + 1)] __complete jobs 2>/dev/null\n")
+	b.WriteString("    else\n")
+	b.WriteString("        vai __complete jobs 2>/dev/null\n")
+	b.WriteString("    end\n")
+	b.WriteString("end\n\n")
+
+	b.WriteString("# Commands\n")
+	for _, name := range visibleCommands() {
+		fmt.Fprintf(&b, "complete -c vai -n '__vai_using \"\"' -f -a %s -d %s\n", name, fishQuote(subcommands[name].usage))
+	}
+	b.WriteString("complete -c vai -n '__vai_using \"\"' -a '(__fish_complete_command)'\n")
+
+	b.WriteString("\n# Flags\n")
+	for _, name := range append([]string{runMode}, visibleCommands()...) {
+		for _, f := range commandFlags(name) {
+			line := fmt.Sprintf("complete -c vai -n '__vai_using %s' -l %s", fishQuote(name), f.name)
+			if f.short != "" {
+				line += " -s " + f.short
+			}
+			switch {
+			case f.value && slices.Contains(fileFlags, f.name):
+				line += " -r -F"
+			case f.value:
+				line += " -x"
+			}
+			b.WriteString(line + " -d " + fishQuote(f.usage) + "\n")
+		}
+	}
+
+	b.WriteString("\n# Arguments\n")
+	for _, name := range visibleCommands() {
+		switch subcommands[name].arg {
+		case "file":
+			fmt.Fprintf(&b, "complete -c vai -n '__vai_using %s' -F\n", name)
+		case "shell":
+			fmt.Fprintf(&b, "complete -c vai -n '__vai_using %s' -f -a '%s'\n", name, strings.Join(completionShells, " "))
+		case "job":
+			fmt.Fprintf(&b, "complete -c vai -n '__vai_using %s' -f -a '(__vai_jobs)'\n", name)
+		default:
+			fmt.Fprintf(&b, "complete -c vai -n '__vai_using %s' -f\n", name)
+		}
+	}
+	return b.String()
+}
+
+// fishQuote quotes a word for fish, inside the single quotes of a condition as well
+func fishQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`).Replace(s) + `"`
+}
+
+// powershellCompletion returns the PowerShell completion script
+func powershellCompletion() string {
+	var b strings.Builder
+	b.WriteString("# PowerShell completion for vai, load it with: vai completion powershell | Out-String | Invoke-Expression\n\n")
+	b.WriteString("Register-ArgumentCompleter -Native -CommandName vai -ScriptBlock {\n")
+	b.WriteString("    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+
+	b.WriteString("    $commands = @{\n")
+	for _, name := range visibleCommands() {
+		fmt.Fprintf(&b, "        %s = %s\n", psQuote(name), psQuote(subcommands[name].usage))
+	}
+	b.WriteString("    }\n")
+	b.WriteString("    $flags = @{\n")
+	for _, name := range append([]string{runMode}, visibleCommands()...) {
+		fmt.Fprintf(&b, "        %s = @(%s)\n", psQuote(name), psList(flagWords(commandFlags(name))))
+	}
+	b.WriteString("    }\n")
+	fmt.Fprintf(&b, "    $valueFlags = @(%s)\n\n", psList(append(valueFlagWords(false), valueFlagWords(true)...)))
+
+	b.WriteString("    $words = @($commandAst.CommandElements | Where-Object { $_.Extent.EndOffset -lt $cursorPosition } | ForEach-Object { $_.ToString() })\n")
+	b.WriteString("    $cmd = ''\n")
+	b.WriteString("    $config = ''\n")
+	b.WriteString("    for ($i = 1; $i -lt $words.Count; $i++) {\n")
+	b.WriteString("        $w = $words[$i]\n")
+	b.WriteString("        if ($w -eq '--') { return }\n")
+	b.WriteString("        if ($w -eq '--config' -and $i + 1 -lt $words.Count) { $config = $words[$i + 1] }\n")
+	b.WriteString("        if ($valueFlags -contains $w) { $i++; continue }\n")
+	b.WriteString("        if ($w.StartsWith('-')) { continue }\n")
+	b.WriteString("        $cmd = $w\n")
+	b.WriteString("        break\n")
+	b.WriteString("    }\n")
+	// Values of flags fall back to path completion
+	b.WriteString("    if ($words.Count -gt 1 -and $valueFlags -contains $words[-1]) { return }\n\n")
+
+	b.WriteString("    if ($wordToComplete.StartsWith('-')) {\n")
+	b.WriteString("        if (-not $flags.ContainsKey($cmd)) { return }\n")
+	b.WriteString("        $candidates = $flags[$cmd]\n")
+	b.WriteString("    } else {\n")
+	b.WriteString("        switch ($cmd) {\n")
+	b.WriteString("            '' { $candidates = $commands.Keys }\n")
+	for _, name := range visibleCommands() {
+		switch subcommands[name].arg {
+		case "shell":
+			fmt.Fprintf(&b, "            %s { $candidates = @(%s) }\n", psQuote(name), psList(completionShells))
+		case "job":
+			fmt.Fprintf(&b, "            %s {\n", psQuote(name))
+			b.WriteString("                if ($config) { $candidates = @(vai --config $config __complete jobs 2>$null) }\n")
+			b.WriteString("                else { $candidates = @(vai __complete jobs 2>$null) }\n")
+			b.WriteString("            }\n")
+		}
+	}
+	b.WriteString("            default { return }\n")
+	b.WriteString("        }\n")
+	b.WriteString("    }\n\n")
+
+	b.WriteString("    $candidates | Where-Object { $_ -like \"$wordToComplete*\" } | Sort-Object | ForEach-Object {\n")
+	b.WriteString("        $tip = if ($commands.ContainsKey($_)) { $commands[$_] } else { $_ }\n")
+	b.WriteString("        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $tip)\n")
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// psQuote quotes a string for PowerShell
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// psList formats strings as the items of a PowerShell array
+func psList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = psQuote(s)
+	}
+	return strings.Join(quoted, ", ")
+}
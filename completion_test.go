@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCompletionScript(t *testing.T) {
+	for _, shell := range completionShells {
+		t.Run(shell, func(t *testing.T) {
+			script, err := completionScript(shell)
+			if err != nil {
+				t.Fatalf("completionScript failed: %v", err)
+			}
+			for _, expected := range []string{"validate", "migrate", "completion", "interactive", "powershell"} {
+				if !strings.Contains(script, expected) {
+					t.Errorf("Expected the %s script to contain %q", shell, expected)
+				}
+			}
+			if strings.Contains(script, "__complete'") || strings.Contains(script, " __complete)") {
+				t.Errorf("Expected the internal command to stay hidden in the %s script", shell)
+			}
+		})
+	}
+
+	if _, err := completionScript("tcsh"); err == nil {
+		t.Error("Expected an error for an unknown shell")
+	}
+}
+
+func TestCompletionScript_Bash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not installed")
+	}
+	script, _ := completionScript("bash")
+	path := filepath.Join(t.TempDir(), "vai.bash")
+	os.WriteFile(path, []byte(script), 0644)
+
+	// Complete a few command lines through the generated function
+	out, err := exec.Command(bash, "-c", `
+source "$1"
+complete_line() {
+	COMP_WORDS=("$@"); COMP_CWORD=$((${#COMP_WORDS[@]} - 1)); COMPREPLY=()
+	_vai
+	echo "${COMPREPLY[*]}"
+}
+complete_line vai vali
+complete_line vai --path ./cmd --deb
+complete_line vai completion f
+complete_line vai init --inter
+`, "bash", path).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash failed: %v\n%s", err, out)
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	expected := []string{"validate", "--debug", "fish", "--interactive"}
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d completions, got %q", len(expected), out)
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(lines[i], e) {
+			t.Errorf("Expected completion %q, got %q", e, lines[i])
+		}
+	}
+}
+
+func TestRunComplete(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "vai.yml")
+	os.WriteFile(config, []byte("jobs:\n  test: go test ./...\n  api: go run ./cmd/api\n"), 0644)
+
+	out := captureOutput(func() {
+		if code := runComplete(&Args{Input: "jobs", ConfigFile: config}); code != 0 {
+			t.Errorf("Expected exit code 0, got %d", code)
+		}
+	})
+	if out != "api\ntest\n" {
+		t.Errorf("Expected the sorted job names, got %q", out)
+	}
+
+	// A missing config completes nothing
+	out = captureOutput(func() {
+		runComplete(&Args{Input: "jobs", ConfigFile: filepath.Join(dir, "missing.yml")})
+	})
+	if out != "" {
+		t.Errorf("Expected no completion, got %q", out)
+	}
+}
+
+func TestParseArgs_Completion(t *testing.T) {
+	cli := mustParseArgs(t, []string{"completion", "zsh"})
+	if cli.Command != "completion" || cli.Shell != "zsh" {
+		t.Errorf("Expected completion for zsh, got %+v", cli)
+	}
+	cli = mustParseArgs(t, []string{"--config", "dev.yml", "__complete", "jobs"})
+	if cli.Command != "__complete" || cli.Input != "jobs" || cli.ConfigFile != "dev.yml" {
+		t.Errorf("Expected job completion from dev.yml, got %+v", cli)
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// stateDir is where vai keeps its runtime state
+var stateDir = ".vai"
+
+// fingerprintDir returns the directory holding step fingerprints
+func fingerprintDir() string {
+	return filepath.Join(stateDir, "fingerprints")
+}
+
+// errNoInputs is returned when the inputs globs match no file
+var errNoInputs = errors.New("inputs match no files")
+
+// stepKey identifies a step across runs, a change of its env invalidates it
+func (j *Job) stepKey() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00", j.Name, j.Cmd, strings.Join(j.Params, "\x00"))
+	fmt.Fprintf(h, "%s\x00%s\x00", strings.Join(j.Inputs, "\x00"), strings.Join(j.Outputs, "\x00"))
+	for _, key := range slices.Sorted(maps.Keys(j.Env)) {
+		fmt.Fprintf(h, "%s=%s\x00", key, j.Env[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprint hashes the content of every file matched by the inputs globs
+func (j *Job) fingerprint() (string, error) {
+	files, err := expandInputs(j.Inputs)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", errNoInputs
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		fh := sha256.New()
+		_, err = io.Copy(fh, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%x\x00", filepath.ToSlash(file), fh.Sum(nil))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// upToDate reports whether the step can be skipped
+func (j *Job) upToDate() bool {
+	if len(j.Inputs) == 0 {
+		return false
+	}
+
+	sum, err := j.fingerprint()
+	if errors.Is(err, errNoInputs) {
+		logger.log(SeverityWarn, OpWarn, "Fingerprint: inputs %v of %s match no files, running it", j.Inputs, j.Cmd)
+		return false
+	}
+	if err != nil {
+		logger.log(SeverityDebug, OpWarn, "Fingerprint: failed to hash inputs for %s: %v", j.Cmd, err)
+		return false
+	}
+
+	for _, out := range j.Outputs {
+		if _, err := os.Stat(out); err != nil {
+			logger.log(SeverityDebug, OpInfo, "Fingerprint: output %s missing, running %s", out, j.Cmd)
+			return false
+		}
+	}
+
+	last, err := os.ReadFile(filepath.Join(fingerprintDir(), j.stepKey()))
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(string(last)) == sum
+}
+
+// saveFingerprint stores the fingerprint of the inputs after a successful run, steps may rewrite them
+func (j *Job) saveFingerprint() {
+	if len(j.Inputs) == 0 {
+		return
+	}
+	sum, err := j.fingerprint()
+	if err != nil {
+		logger.log(SeverityDebug, OpWarn, "Fingerprint: not saved for %s: %v", j.Cmd, err)
+		return
+	}
+	if err := os.MkdirAll(fingerprintDir(), 0755); err != nil {
+		logger.log(SeverityError, OpError, "Failed to create state dir: %v", err)
+		return
+	}
+	if err := os.WriteFile(filepath.Join(fingerprintDir(), j.stepKey()), []byte(sum+"\n"), 0644); err != nil {
+		logger.log(SeverityError, OpError, "Failed to save fingerprint: %v", err)
+	}
+}
+
+// expandInputs resolves inputs globs into a sorted list of unique files
+func expandInputs(patterns []string) ([]string, error) {
+	seen := make(map[string]struct{})
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := globFiles(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inputs pattern %q: %v", pattern, err)
+		}
+		for _, m := range matches {
+			info, err := os.Stat(m)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if _, ok := seen[m]; !ok {
+				seen[m] = struct{}{}
+				files = append(files, m)
+			}
+		}
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// globFiles expands a glob, walking the tree for doublestar and brace patterns
+func globFiles(pattern string) ([]string, error) {
+	if !strings.Contains(pattern, "{") && !strings.Contains(pattern, "**") {
+		return filepath.Glob(pattern)
+	}
+
+	// Walk from the longest directory prefix without wildcards
+	pattern = filepath.ToSlash(pattern)
+	root := "."
+	rest := pattern
+	if i := strings.IndexAny(pattern, "*?[{"); i > 0 {
+		if j := strings.LastIndex(pattern[:i], "/"); j >= 0 {
+			root = pattern[:max(j, 1)]
+			rest = pattern[j+1:]
+		}
+	}
+
+	expr, err := globToRegex(rest)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	err = filepath.WalkDir(filepath.FromSlash(root), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(root), path)
+		if err == nil && re.MatchString(filepath.ToSlash(rel)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	return matches, err
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "b.go"), []byte("b"), 0644)
+	os.WriteFile(filepath.Join(dir, "a.go"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0644)
+	os.Mkdir(filepath.Join(dir, "sub.go"), 0755)
+
+	files, err := expandInputs([]string{filepath.Join(dir, "*.go"), filepath.Join(dir, "a.go")})
+	if err != nil {
+		t.Fatalf("expandInputs failed: %v", err)
+	}
+	expected := []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	os.MkdirAll(filepath.Join(dir, "api", "v1"), 0755)
+	os.WriteFile(filepath.Join(dir, "api", "v1", "svc.proto"), []byte("p"), 0644)
+	files, err = expandInputs([]string{filepath.Join(dir, "**", "*.{proto,txt}")})
+	if err != nil {
+		t.Fatalf("expandInputs failed: %v", err)
+	}
+	expected = []string{filepath.Join(dir, "api", "v1", "svc.proto"), filepath.Join(dir, "c.txt")}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	if _, err := expandInputs([]string{"[invalid"}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.txt")
+	os.WriteFile(input, []byte("one"), 0644)
+
+	job := &Job{Cmd: "true", Inputs: []string{input}}
+	first, err := job.fingerprint()
+	if err != nil {
+		t.Fatalf("fingerprint failed: %v", err)
+	}
+
+	// Touching a file without changing it keeps the fingerprint
+	os.WriteFile(input, []byte("one"), 0644)
+	if second, _ := job.fingerprint(); second != first {
+		t.Error("Expected fingerprint to be stable for identical content")
+	}
+
+	os.WriteFile(input, []byte("two"), 0644)
+	if third, _ := job.fingerprint(); third == first {
+		t.Error("Expected fingerprint to change when content changes")
+	}
+}
+
+func TestRun_SkipsUpToDateSteps(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping executor tests on Windows due to shell command differences")
+	}
+	resetGlobals()
+
+	dir := t.TempDir()
+	oldStateDir := stateDir
+	stateDir = filepath.Join(dir, ".vai")
+	defer func() { stateDir = oldStateDir }()
+
+	input := filepath.Join(dir, "in.txt")
+	output := filepath.Join(dir, "out.txt")
+	counter := filepath.Join(dir, "counter")
+	os.WriteFile(input, []byte("one"), 0644)
+
+	job := Job{
+		Name:    "gen",
+		Cmd:     "sh",
+		Params:  []string{"-c", "cp " + input + " " + output + " && echo run >> " + counter},
+		Inputs:  []string{input},
+		Outputs: []string{output},
+	}
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	job.run(context.Background())
+	job.run(context.Background())
+	if runs() != 1 {
+		t.Fatalf("Expected the second run to be skipped, got %d runs", runs())
+	}
+
+	os.WriteFile(input, []byte("two"), 0644)
+	job.run(context.Background())
+	if runs() != 2 {
+		t.Fatalf("Expected a run after the inputs changed, got %d runs", runs())
+	}
+
+	os.Remove(output)
+	job.run(context.Background())
+	if runs() != 3 {
+		t.Fatalf("Expected a run after the outputs were removed, got %d runs", runs())
+	}
+}
+
+func TestRun_FingerprintEdgeCases(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping executor tests on Windows due to shell command differences")
+	}
+	resetGlobals()
+
+	dir := t.TempDir()
+	oldStateDir := stateDir
+	stateDir = filepath.Join(dir, ".vai")
+	defer func() { stateDir = oldStateDir }()
+
+	counter := filepath.Join(dir, "counter")
+	runs := func() int {
+		data, _ := os.ReadFile(counter)
+		return strings.Count(string(data), "run")
+	}
+
+	// A step rewriting its own inputs is up to date afterwards
+	input := filepath.Join(dir, "in.txt")
+	os.WriteFile(input, []byte("unformatted"), 0644)
+	format := Job{
+		Name:   "fmt",
+		Cmd:    "sh",
+		Params: []string{"-c", "echo formatted > " + input + " && echo run >> " + counter},
+		Inputs: []string{input},
+	}
+	format.run(context.Background())
+	format.run(context.Background())
+	if runs() != 1 {
+		t.Fatalf("Expected the fingerprint to be taken after the run, got %d runs", runs())
+	}
+
+	// Changing the env invalidates the step
+	format.Env = map[string]string{"GOFLAGS": "-mod=vendor"}
+	format.run(context.Background())
+	if runs() != 2 {
+		t.Fatalf("Expected a run after the env changed, got %d runs", runs())
+	}
+
+	// Inputs matching no file never make a step up to date
+	missing := Job{
+		Name:   "gen",
+		Cmd:    "sh",
+		Params: []string{"-c", "echo run >> " + counter},
+		Inputs: []string{filepath.Join(dir, "*.proto")},
+	}
+	missing.run(context.Background())
+	missing.run(context.Background())
+	if runs() != 4 {
+		t.Fatalf("Expected steps without matching inputs to always run, got %d runs", runs())
+	}
+}
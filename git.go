@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// gitQuietPeriod is how long a repository must stay quiet before held events are released
+const gitQuietPeriod = 500 * time.Millisecond
+
+// gitStateFiles are the files git keeps while an operation is in progress
+var gitStateFiles = []struct{ name, op string }{
+	{"rebase-merge", "rebase"},
+	{"rebase-apply", "rebase"},
+	{"MERGE_HEAD", "merge"},
+	{"CHERRY_PICK_HEAD", "cherry-pick"},
+	{"REVERT_HEAD", "revert"},
+}
+
+// gitRepo is a watched repository and the last HEAD seen
+type gitRepo struct {
+	dir  string
+	head []byte
+}
+
+// gitIndexLock is created by every command writing the index, a checkout rewrites the tree before HEAD changes
+const gitIndexLock = "index.lock"
+
+// gitGuard holds events while a git operation rewrites the working tree
+type gitGuard struct {
+	mu      sync.Mutex
+	repos   []*gitRepo
+	holding bool
+	op      string
+	held    []fswatcher.WatchEvent
+	timer   *time.Timer
+	quiet   time.Duration
+	release chan []fswatcher.WatchEvent
+}
+
+// newGitGuard finds the repositories containing the watched roots
+func newGitGuard(roots []string) *gitGuard {
+	g := &gitGuard{quiet: gitQuietPeriod, release: make(chan []fswatcher.WatchEvent, 1)}
+	seen := make(map[string]struct{})
+	for _, r := range roots {
+		root, _ := resolvePath(r)
+		repo := findRepo(root)
+		if repo == "" {
+			continue
+		}
+		dir := filepath.Join(repo, ".git")
+		if _, ok := seen[dir]; ok {
+			continue
+		}
+		seen[dir] = struct{}{}
+		head, _ := os.ReadFile(filepath.Join(dir, "HEAD"))
+		g.repos = append(g.repos, &gitRepo{dir: dir, head: head})
+	}
+	return g
+}
+
+// inGitDir reports whether a path is inside the .git directory of a watched repository
+func (g *gitGuard) inGitDir(path string) bool {
+	if g == nil {
+		return false
+	}
+	abs, canonical := resolvePath(path)
+	for _, r := range g.repos {
+		if _, ok := within(r.dir, abs); ok {
+			return true
+		}
+		if _, ok := within(r.dir, canonical); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// operation returns the git operation in progress, if any
+func (g *gitGuard) operation() string {
+	for _, r := range g.repos {
+		for _, f := range gitStateFiles {
+			if _, err := os.Stat(filepath.Join(r.dir, f.name)); err == nil {
+				return f.op
+			}
+		}
+		if head, _ := os.ReadFile(filepath.Join(r.dir, "HEAD")); !bytes.Equal(head, r.head) {
+			return "checkout"
+		}
+	}
+	return ""
+}
+
+// indexLocked reports whether git is writing the index of a watched repository
+func (g *gitGuard) indexLocked() bool {
+	for _, r := range g.repos {
+		if _, err := os.Stat(filepath.Join(r.dir, gitIndexLock)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hold checks for a git operation and keeps the event until the repository is quiet
+func (g *gitGuard) hold(event fswatcher.WatchEvent) bool {
+	if g == nil || len(g.repos) == 0 {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.holding {
+		// Operations are only looked for when git itself writes to .git
+		if !g.inGitDir(event.Path) {
+			return false
+		}
+		// A busy index may be a checkout starting, tree events wait until it's quiet
+		if g.operation() == "" && !g.indexLocked() {
+			return false
+		}
+		g.holding = true
+		g.timer = time.AfterFunc(g.quiet, g.settle)
+	} else {
+		// Every new event extends the wait
+		g.timer.Reset(g.quiet)
+	}
+	if g.op == "" && g.inGitDir(event.Path) {
+		if op := g.operation(); op != "" {
+			g.op = op
+			logger.log(SeverityWarn, OpInfo, "git %s detected, waiting…", op)
+		}
+	}
+
+	if g.inGitDir(event.Path) {
+		return true
+	}
+	g.held = append(g.held, event)
+	return true
+}
+
+// settle releases the held events once no git operation is in progress
+func (g *gitGuard) settle() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if op := g.operation(); op != "" && op != "checkout" {
+		g.timer.Reset(g.quiet)
+		return
+	}
+	for _, r := range g.repos {
+		r.head, _ = os.ReadFile(filepath.Join(r.dir, "HEAD"))
+	}
+
+	select {
+	case g.release <- g.held:
+		if g.op != "" {
+			logger.log(SeverityInfo, OpSuccess, "git %s finished, %d changes held", g.op, len(g.held))
+		} else {
+			logger.log(SeverityDebug, OpInfo, "git index released, %d changes held", len(g.held))
+		}
+		g.holding = false
+		g.op = ""
+		g.held = nil
+	default:
+		// The previous release is still pending
+		g.timer.Reset(g.quiet)
+	}
+}
+
+// releases returns the channel of events held during a git operation
+func (g *gitGuard) releases() <-chan []fswatcher.WatchEvent {
+	if g == nil {
+		return nil
+	}
+	return g.release
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+func TestGitGuard(t *testing.T) {
+	resetGlobals()
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	gitDir := filepath.Join(root, ".git")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	src := newFakeSource()
+	v := &Vai{
+		cwd:     root,
+		manager: newManager(),
+		source:  src,
+		Config:  Config{DisableHashCheck: true},
+		Jobs: map[string]Job{
+			"build": {Cmd: "go", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"*.go"}}},
+		},
+	}
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	v.git = newGitGuard([]string{root})
+	v.git.quiet = 50 * time.Millisecond
+	got := dispatched(v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go v.runEventLoop(ctx)
+
+	waitFor := func(expected []string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if result := got(); len(result) >= len(expected) {
+				if !reflect.DeepEqual(result, expected) {
+					t.Fatalf("Expected dispatches %v, got %v", expected, result)
+				}
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Timed out waiting for %v, got %v", expected, got())
+	}
+
+	// Events inside .git are never dispatched
+	src.emit(filepath.Join(gitDir, "index"), fswatcher.EventMod)
+	src.emit(filepath.Join(root, "main.go"), fswatcher.EventMod)
+	waitFor([]string{"build:main.go"})
+
+	// index.lock alone, as written by git status or git add, only delays events until the index is quiet
+	lock := filepath.Join(gitDir, "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	src.emit(lock, fswatcher.EventCreate)
+	src.emit(filepath.Join(root, "util.go"), fswatcher.EventMod)
+	os.Remove(lock)
+	src.emit(lock, fswatcher.EventRemove)
+	waitFor([]string{"build:main.go", "build:util.go"})
+
+	// A rebase holds events until the repository is quiet
+	rebase := filepath.Join(gitDir, "rebase-merge")
+	os.MkdirAll(rebase, 0755)
+	src.emit(rebase, fswatcher.EventCreate)
+	src.emit(filepath.Join(root, "a.go"), fswatcher.EventMod)
+	src.emit(filepath.Join(root, "b.go"), fswatcher.EventCreate)
+	src.emit(filepath.Join(root, "a.go"), fswatcher.EventMod)
+
+	time.Sleep(150 * time.Millisecond)
+	if result := got(); len(result) != 2 {
+		t.Fatalf("Expected events to be held while rebase-merge exists, got %v", result)
+	}
+
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644)
+	os.Remove(rebase)
+	src.emit(rebase, fswatcher.EventRemove)
+	waitFor([]string{"build:main.go", "build:util.go", "build:a.go", "build:b.go"})
+
+	// Once released, events are dispatched right away
+	src.emit(filepath.Join(root, "c.go"), fswatcher.EventMod)
+	waitFor([]string{"build:main.go", "build:util.go", "build:a.go", "build:b.go", "build:c.go"})
+}
+
+func TestGitGuard_Checkout(t *testing.T) {
+	resetGlobals()
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	gitDir := filepath.Join(root, ".git")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	g := newGitGuard([]string{root})
+	g.quiet = 50 * time.Millisecond
+
+	// git checkout locks the index, rewrites the tree, then moves HEAD and renames the lock
+	lock := filepath.Join(gitDir, "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	steps := []fswatcher.WatchEvent{
+		{Path: lock},
+		{Path: filepath.Join(root, "a.go")},
+		{Path: filepath.Join(root, "b.go")},
+	}
+	for _, event := range steps {
+		if !g.hold(event) {
+			t.Fatalf("Expected %s to be held while the index is locked", event.Path)
+		}
+	}
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644)
+	os.Rename(lock, filepath.Join(gitDir, "index"))
+	g.hold(fswatcher.WatchEvent{Path: filepath.Join(gitDir, "HEAD")})
+	g.hold(fswatcher.WatchEvent{Path: filepath.Join(gitDir, "index")})
+
+	select {
+	case held := <-g.releases():
+		if len(held) != 2 || held[0].Path != steps[1].Path || held[1].Path != steps[2].Path {
+			t.Errorf("Expected the tree changes to be released together, got %v", held)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for the checkout to settle")
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.holding || g.op != "" {
+		t.Errorf("Expected the guard to be reset, holding %v op %q", g.holding, g.op)
+	}
+}
+
+func TestGitGuard_HoldOnlyFromGitDir(t *testing.T) {
+	resetGlobals()
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	gitDir := filepath.Join(root, ".git")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	g := newGitGuard([]string{root})
+	g.quiet = time.Hour
+	os.WriteFile(filepath.Join(gitDir, "MERGE_HEAD"), nil, 0644)
+
+	// Working tree events don't look for operations
+	if g.hold(fswatcher.WatchEvent{Path: filepath.Join(root, "main.go")}) {
+		t.Fatal("Expected a working tree event not to start a hold")
+	}
+	if !g.hold(fswatcher.WatchEvent{Path: filepath.Join(gitDir, "MERGE_HEAD")}) || g.op != "merge" {
+		t.Fatalf("Expected a .git event to detect the merge, got %q", g.op)
+	}
+	g.timer.Stop()
+}
+
+func TestGitGuard_Operation(t *testing.T) {
+	root := t.TempDir()
+	gitDir := filepath.Join(root, ".git")
+	os.MkdirAll(gitDir, 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	g := newGitGuard([]string{filepath.Join(root, "sub")})
+	if len(g.repos) != 1 {
+		t.Fatalf("Expected the parent repository to be found, got %d", len(g.repos))
+	}
+	if op := g.operation(); op != "" {
+		t.Errorf("Expected no operation, got %q", op)
+	}
+
+	os.MkdirAll(filepath.Join(gitDir, "rebase-merge"), 0755)
+	if op := g.operation(); op != "rebase" {
+		t.Errorf("Expected rebase, got %q", op)
+	}
+	os.Remove(filepath.Join(gitDir, "rebase-merge"))
+
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("0123456789abcdef\n"), 0644)
+	if op := g.operation(); op != "checkout" {
+		t.Errorf("Expected checkout on HEAD change, got %q", op)
+	}
+
+	if !g.inGitDir(filepath.Join(gitDir, "refs", "heads", "main")) || g.inGitDir(filepath.Join(root, "main.go")) {
+		t.Error("inGitDir misreported paths")
+	}
+}
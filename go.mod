@@ -3,6 +3,7 @@ module github.com/sgtdi/vai
 go 1.25.4
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/sgtdi/fswatcher v1.2.0
 	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ignoreRule is a single compiled line of an ignore file
+type ignoreRule struct {
+	base    string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignorer applies .gitignore, .git/info/exclude and .vaiignore rules
+type ignorer struct {
+	rules []ignoreRule
+	dirs  []string
+	files map[string]struct{}
+	// roots are trigger paths ignored by rules above them, only their own rules apply inside
+	roots []string
+}
+
+// loadIgnorer reads the ignore files for the given roots, gitignore rules are skipped if disabled
+func loadIgnorer(roots []string, gitignore bool) *ignorer {
+	ig := &ignorer{files: make(map[string]struct{})}
+
+	for _, r := range roots {
+		root, _ := resolvePath(r)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			continue
+		}
+
+		if gitignore {
+			// Rules from the repository down to the root apply too
+			if repo := findRepo(root); repo != "" {
+				ig.load(filepath.Join(repo, ".git", "info", "exclude"), repo)
+				for _, dir := range ancestors(repo, root) {
+					ig.load(filepath.Join(dir, ".gitignore"), dir)
+				}
+			}
+			ig.load(filepath.Join(root, ".gitignore"), root)
+		}
+		ig.load(filepath.Join(root, ".vaiignore"), root)
+
+		// An explicit trigger path wins over the ignore files, e.g. a build directory in .gitignore
+		if ig.ignored(root, true) {
+			logger.log(SeverityWarn, OpWarn, "Trigger path %s is ignored by an ignore file, watching it anyway", r)
+			ig.roots = append(ig.roots, root)
+		}
+
+		// Walk the tree, skipping ignored directories
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || !d.IsDir() {
+				return nil
+			}
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			if path != root && ig.match(path, true, ig.scope(path)) {
+				ig.dirs = append(ig.dirs, path)
+				return fs.SkipDir
+			}
+			if gitignore && path != root {
+				ig.load(filepath.Join(path, ".gitignore"), path)
+			}
+			return nil
+		})
+	}
+
+	// Ignored directories containing a trigger path stay watched
+	ig.dirs = slices.DeleteFunc(ig.dirs, func(dir string) bool {
+		return slices.ContainsFunc(ig.roots, func(root string) bool {
+			_, ok := within(dir, root)
+			return ok
+		})
+	})
+
+	if len(ig.rules) > 0 {
+		logger.log(SeverityDebug, OpInfo, "Loaded %d ignore rules, %d directories ignored", len(ig.rules), len(ig.dirs))
+	}
+	return ig
+}
+
+// load parses an ignore file whose patterns are relative to base
+func (ig *ignorer) load(path, base string) {
+	if _, ok := ig.files[path]; ok {
+		return
+	}
+	ig.files[path] = struct{}{}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text(), base); ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+	logger.log(SeverityDebug, OpInfo, "Loaded ignore file %s", path)
+}
+
+// ignored reports whether a path or any of its parent directories is ignored
+func (ig *ignorer) ignored(path string, isDir bool) bool {
+	if ig == nil || len(ig.rules) == 0 {
+		return false
+	}
+	abs, _ := resolvePath(path)
+	scope := ig.scope(abs)
+
+	// A file inside an ignored directory can't be re-included
+	for dir := filepath.Dir(abs); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if ig.match(dir, true, scope) {
+			return true
+		}
+	}
+	return ig.match(abs, isDir, scope)
+}
+
+// scope returns the deepest ignored trigger path containing a path, empty if none does
+func (ig *ignorer) scope(path string) string {
+	scope := ""
+	for _, root := range ig.roots {
+		if _, ok := within(root, path); ok && len(root) > len(scope) {
+			scope = root
+		}
+	}
+	return scope
+}
+
+// match applies the rules in order, the last matching rule wins. Inside a scope only the rules of its ignore files apply
+func (ig *ignorer) match(path string, isDir bool, scope string) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if scope != "" {
+			if _, ok := within(scope, rule.base); !ok {
+				continue
+			}
+		}
+		rel, ok := within(rule.base, path)
+		if !ok || rel == "." {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// parseIgnoreLine compiles a gitignore pattern relative to base
+func parseIgnoreLine(line, base string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base}
+	if after, ok := strings.CutPrefix(line, "!"); ok {
+		rule.negate = true
+		line = after
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if after, ok := strings.CutSuffix(line, "/"); ok {
+		rule.dirOnly = true
+		line = after
+	}
+
+	// Patterns without a slash match at any level
+	if strings.Contains(line, "/") {
+		line = strings.TrimPrefix(line, "/")
+	} else {
+		line = "**/" + line
+	}
+
+	// Braces are literal in gitignore
+	line = strings.NewReplacer("{", `\{`, "}", `\}`).Replace(line)
+	expr, err := globToRegex(line)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// findRepo returns the closest directory containing .git
+func findRepo(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, ".git")); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ancestors lists the directories from top down to dir, both included
+func ancestors(top, dir string) []string {
+	var dirs []string
+	for {
+		dirs = append([]string{dir}, dirs...)
+		if dir == top {
+			return dirs
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+// ignoredDirRegex returns watcher exclusions for the ignored directories
+func (ig *ignorer) ignoredDirRegex() []string {
+	if ig == nil {
+		return nil
+	}
+	sep := regexp.QuoteMeta(string(filepath.Separator))
+	patterns := make([]string, 0, len(ig.dirs))
+	for _, dir := range ig.dirs {
+		patterns = append(patterns, "^"+regexp.QuoteMeta(dir)+"("+sep+"|$)")
+	}
+	return patterns
+}
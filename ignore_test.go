@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnorer(t *testing.T) {
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	write := func(rel, content string) {
+		path := filepath.Join(root, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	os.MkdirAll(filepath.Join(root, ".git", "info"), 0755)
+	write(".git/info/exclude", "secret.txt\n")
+	write(".gitignore", "# deps\nnode_modules/\n/tmp\n*.log\n!keep.log\nbuild/**/*.o\n")
+	write("web/.gitignore", "dist/\n")
+	write(".vaiignore", "docs/\n")
+	write("node_modules/pkg/index.js", "")
+	write("web/dist/app.js", "")
+	write("web/src/app.js", "")
+	write("tmp/main", "")
+	write("sub/tmp/main.go", "")
+	write("docs/index.md", "")
+
+	ig := loadIgnorer([]string{root}, true)
+
+	testCases := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", false},
+		{"node_modules/pkg/index.js", true},
+		{"web/dist/app.js", true},
+		{"web/src/app.js", false},
+		{"tmp/main", true},
+		{"sub/tmp/main.go", false},
+		{"server.log", true},
+		{"logs/keep.log", false},
+		{"build/x/y/z.o", true},
+		{"secret.txt", true},
+		{"docs/index.md", true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			if result := ig.ignored(filepath.Join(root, tc.path), false); result != tc.expected {
+				t.Errorf("ignored(%q) = %v, want %v", tc.path, result, tc.expected)
+			}
+		})
+	}
+
+	// Ignored directories are reported to the watcher
+	if len(ig.ignoredDirRegex()) != 4 {
+		t.Errorf("Expected 4 ignored directories, got %v", ig.dirs)
+	}
+
+	t.Run("explicit trigger paths win over ignore files", func(t *testing.T) {
+		write("web/dist/.vaiignore", "*.map\n")
+		dist := filepath.Join(root, "web", "dist")
+		for _, roots := range [][]string{{root, dist}, {dist, root}} {
+			ig := loadIgnorer(roots, true)
+			if ig.ignored(filepath.Join(dist, "app.js"), false) || ig.ignored(dist, true) {
+				t.Errorf("Expected files of the trigger path %s to be watched", dist)
+			}
+			if !ig.ignored(filepath.Join(dist, "app.js.map"), false) {
+				t.Error("Expected the ignore file of the trigger path to apply")
+			}
+			if !ig.ignored(filepath.Join(root, "node_modules/pkg/index.js"), false) {
+				t.Error("Expected other ignored directories to stay ignored")
+			}
+			if len(ig.ignoredDirRegex()) != 3 {
+				t.Errorf("Expected the trigger path not to be excluded from the watcher, got %v", ig.dirs)
+			}
+		}
+	})
+
+	t.Run("gitignore disabled keeps vaiignore", func(t *testing.T) {
+		ig := loadIgnorer([]string{root}, false)
+		if ig.ignored(filepath.Join(root, "node_modules/pkg/index.js"), false) {
+			t.Error("Expected node_modules not to be ignored")
+		}
+		if !ig.ignored(filepath.Join(root, "docs/index.md"), false) {
+			t.Error("Expected docs to be ignored by .vaiignore")
+		}
+	})
+}
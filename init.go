@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// project is what vai init found in a directory
+type project struct {
+	module     string
+	workspace  []string
+	mains      []string
+	tests      bool
+	templ      bool
+	sqlc       bool
+	buf        bool
+	packageMgr string
+	scripts    []string
+}
+
+// initJob is a job proposed by vai init
+type initJob struct {
+	name    string
+	comment string
+	glob    []string
+	steps   [][]string
+}
+
+// goGlobs trigger the jobs running Go code
+var goGlobs = []string{"**/*.go", "go.mod", "go.sum", "go.work"}
+
+// runInit detects the project in the working directory and writes a config file
+func runInit(args *Args, in io.Reader) int {
+	if fileExists(args.ConfigFile) && !args.Force {
+		logger.log(SeverityError, OpError, "%s already exists, use --force to overwrite it", args.ConfigFile)
+		return 1
+	}
+
+	p := detectProject(".")
+	jobs := p.jobs()
+	if args.Interactive {
+		jobs = confirmJobs(jobs, in, os.Stdout)
+	}
+
+	if err := os.WriteFile(args.ConfigFile, []byte(renderInit(p, jobs)), 0644); err != nil {
+		logger.log(SeverityError, OpError, "Failed to write %s: %v", args.ConfigFile, err)
+		return 1
+	}
+	names := make([]string, 0, len(jobs))
+	for _, j := range jobs {
+		names = append(names, j.name)
+	}
+	fmt.Printf("%s %s with jobs: %s\n", green("Created"), args.ConfigFile, strings.Join(names, ", "))
+	return 0
+}
+
+// detectProject inspects a directory for Go modules, main packages, tests and code generators
+func detectProject(dir string) *project {
+	p := &project{}
+
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		p.module = modulePath(string(data))
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "go.work")); err == nil {
+		p.workspace = workspaceModules(string(data))
+	}
+
+	// Main packages at the root of each module and under cmd/
+	modules := []string{"."}
+	for _, m := range p.workspace {
+		if m != "." {
+			modules = append(modules, m)
+		}
+	}
+	for _, m := range modules {
+		if isMainPackage(filepath.Join(dir, m, "main.go")) {
+			p.mains = append(p.mains, m)
+		}
+		cmds, _ := filepath.Glob(filepath.Join(dir, m, "cmd", "*", "main.go"))
+		slices.Sort(cmds)
+		for _, c := range cmds {
+			if isMainPackage(c) {
+				rel, _ := filepath.Rel(dir, filepath.Dir(c))
+				p.mains = append(p.mains, filepath.ToSlash(rel))
+			}
+		}
+	}
+
+	// Tests and generator inputs anywhere in the tree
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "testdata") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(name, "_test.go"):
+			p.tests = true
+		case strings.HasSuffix(name, ".templ"):
+			p.templ = true
+		case name == "sqlc.yaml" || name == "sqlc.yml" || name == "sqlc.json":
+			p.sqlc = true
+		case name == "buf.gen.yaml" || name == "buf.gen.yml":
+			p.buf = true
+		}
+		return nil
+	})
+
+	// Node scripts
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Scripts map[string]string `json:"scripts"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			for name := range pkg.Scripts {
+				p.scripts = append(p.scripts, name)
+			}
+			slices.Sort(p.scripts)
+		}
+		p.packageMgr = "npm"
+		switch {
+		case fileExists(filepath.Join(dir, "pnpm-lock.yaml")):
+			p.packageMgr = "pnpm"
+		case fileExists(filepath.Join(dir, "yarn.lock")):
+			p.packageMgr = "yarn"
+		case fileExists(filepath.Join(dir, "bun.lockb")):
+			p.packageMgr = "bun"
+		}
+	}
+	return p
+}
+
+// jobs proposes the jobs for a detected project
+func (p *project) jobs() []initJob {
+	var jobs []initJob
+
+	// Every proposed name is checked, commands may be called like the other jobs
+	used := make(map[string]bool)
+	unique := func(base string) string {
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s-%d", base, i)
+		}
+		used[name] = true
+		return name
+	}
+
+	// Code generation first, the Go jobs pick up the generated files
+	gen := initJob{name: "generate", comment: "Regenerate code when its sources change"}
+	if p.templ {
+		gen.glob = append(gen.glob, "**/*.templ")
+		gen.steps = append(gen.steps, []string{"templ", "generate"})
+	}
+	if p.sqlc {
+		gen.glob = append(gen.glob, "**/*.sql", "sqlc.{yaml,yml,json}")
+		gen.steps = append(gen.steps, []string{"sqlc", "generate"})
+	}
+	if p.buf {
+		gen.glob = append(gen.glob, "**/*.proto", "buf.gen.{yaml,yml}")
+		gen.steps = append(gen.steps, []string{"buf", "generate"})
+	}
+	if len(gen.steps) > 0 {
+		gen.name = unique(gen.name)
+		jobs = append(jobs, gen)
+	}
+
+	runGlobs := append(slices.Clone(goGlobs), "!**/*_test.go")
+	for _, m := range p.mains {
+		name := unique(mainName(m))
+		pkg := "./" + strings.TrimPrefix(m, "./")
+		if m == "." {
+			pkg = "."
+		}
+		jobs = append(jobs, initJob{
+			name:    name,
+			comment: fmt.Sprintf("Restart %s on every Go change", pkg),
+			glob:    runGlobs,
+			steps:   [][]string{{"go", "run", pkg}},
+		})
+	}
+
+	if p.tests {
+		test := initJob{name: "test", comment: "Run the tests on every Go change", glob: goGlobs}
+		if len(p.workspace) > 0 {
+			// ./... doesn't cross module boundaries
+			for _, m := range p.workspace {
+				pattern := "./..."
+				if m != "." {
+					pattern = "./" + strings.TrimPrefix(m, "./") + "/..."
+				}
+				test.steps = append(test.steps, []string{"go", "test", pattern})
+			}
+		} else {
+			test.steps = [][]string{{"go", "test", "./..."}}
+		}
+		if used["test"] {
+			test.name = "go-test"
+		}
+		test.name = unique(test.name)
+		jobs = append(jobs, test)
+	}
+
+	if p.packageMgr != "" && slices.Contains(p.scripts, "build") {
+		jobs = append(jobs, initJob{
+			name:    unique("web"),
+			comment: "Rebuild the frontend",
+			glob:    []string{"**/*.{js,jsx,ts,tsx,css,scss,html,vue,svelte}", "package.json", "!dist/**", "!build/**"},
+			steps:   [][]string{{p.packageMgr, "run", "build"}},
+		})
+	}
+
+	if len(jobs) == 0 {
+		jobs = append(jobs, initJob{
+			name:    "app",
+			comment: "Nothing was detected, adjust this job to your project",
+			glob:    runGlobs,
+			steps:   [][]string{{"go", "run", "."}},
+		})
+	}
+	return jobs
+}
+
+// confirmJobs asks which proposed jobs to keep
+func confirmJobs(jobs []initJob, in io.Reader, out io.Writer) []initJob {
+	reader := bufio.NewReader(in)
+	var kept []initJob
+	for _, j := range jobs {
+		var cmds []string
+		for _, s := range j.steps {
+			cmds = append(cmds, strings.Join(s, " "))
+		}
+		fmt.Fprintf(out, "Add job %s (%s)? [Y/n] ", cyan(j.name), strings.Join(cmds, " && "))
+		answer, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "n", "no":
+		default:
+			kept = append(kept, j)
+		}
+	}
+	return kept
+}
+
+// renderInit writes the commented config file of the proposed jobs
+func renderInit(p *project, jobs []initJob) string {
+	var b strings.Builder
+	b.WriteString(schemaHeader)
+	b.WriteString("# Generated by vai init")
+	if p.module != "" {
+		fmt.Fprintf(&b, " for %s", p.module)
+	}
+	b.WriteString(", see https://github.com/sgtdi/vai for all options\n\n")
+
+	b.WriteString("config:\n")
+	b.WriteString("  # Log level: debug, info, warn or error\n")
+	b.WriteString("  severity: warn\n")
+	b.WriteString("  # Clear the terminal before each run\n")
+	b.WriteString("  clearCli: false\n\n")
+
+	b.WriteString("jobs:\n")
+	for i, j := range jobs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "  # %s\n", j.comment)
+		fmt.Fprintf(&b, "  %s:\n", j.name)
+		b.WriteString("    trigger:\n")
+		b.WriteString("      paths: [.]\n")
+		fmt.Fprintf(&b, "      glob: [%s]\n", quoteList(j.glob))
+		if len(j.steps) == 1 {
+			fmt.Fprintf(&b, "    cmd: %s\n", j.steps[0][0])
+			fmt.Fprintf(&b, "    params: [%s]\n", quoteList(j.steps[0][1:]))
+			continue
+		}
+		b.WriteString("    series:\n")
+		for _, s := range j.steps {
+			fmt.Fprintf(&b, "      - %s\n", strings.Join(s, " "))
+		}
+	}
+	return b.String()
+}
+
+// quoteList formats strings as the items of a YAML flow sequence
+func quoteList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// modulePath returns the module path declared in a go.mod file
+func modulePath(gomod string) string {
+	for line := range strings.Lines(gomod) {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(rest), `"`)
+		}
+	}
+	return ""
+}
+
+// workspaceModules returns the module directories used by a go.work file
+func workspaceModules(gowork string) []string {
+	var dirs []string
+	inBlock := false
+	for line := range strings.Lines(gowork) {
+		line = strings.TrimSpace(line)
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		switch {
+		case line == "use (":
+			inBlock = true
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock && line != "":
+			dirs = append(dirs, filepath.ToSlash(filepath.Clean(line)))
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, filepath.ToSlash(filepath.Clean(strings.TrimSpace(line[4:]))))
+		}
+	}
+	return dirs
+}
+
+// isMainPackage reports whether a Go file belongs to package main
+func isMainPackage(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "package ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "package ")) == "main"
+		}
+	}
+	return false
+}
+
+// mainName names the job of a main package after its directory
+func mainName(dir string) string {
+	if dir == "." {
+		return "app"
+	}
+	return filepath.Base(dir)
+}
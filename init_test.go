@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectProject(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("go.mod", "module example.com/shop\n\ngo 1.25\n")
+	write("main.go", "package main\n")
+	write("cmd/api/main.go", "// Command api\npackage main\n")
+	write("cmd/tool/tool.go", "package main\n")
+	write("internal/store/store_test.go", "package store\n")
+	write("views/home.templ", "")
+	write("proto/buf.gen.yaml", "")
+	write("node_modules/x/x_test.go", "")
+	write("package.json", `{"scripts": {"build": "vite build", "dev": "vite"}}`)
+	write("pnpm-lock.yaml", "")
+
+	p := detectProject(dir)
+	expected := &project{
+		module:     "example.com/shop",
+		mains:      []string{".", "cmd/api"},
+		tests:      true,
+		templ:      true,
+		buf:        true,
+		packageMgr: "pnpm",
+		scripts:    []string{"build", "dev"},
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, p)
+	}
+
+	var names []string
+	for _, j := range p.jobs() {
+		names = append(names, j.name)
+	}
+	if expected := []string{"generate", "app", "api", "test", "web"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected jobs %v, got %v", expected, names)
+	}
+
+	// The generated file is a valid config
+	content := renderInit(p, p.jobs())
+	errs, _ := splitWarnings(validateConfig("vai.yml", []byte(content)))
+	if len(errs) > 0 {
+		t.Fatalf("Generated config is invalid: %v\n%s", errs, content)
+	}
+	config := filepath.Join(dir, "vai.yml")
+	os.WriteFile(config, []byte(content), 0644)
+	v, err := fromFile(config)
+	if err != nil {
+		t.Fatalf("Failed to load the generated config: %v", err)
+	}
+	if api := v.Jobs["api"]; api.Cmd != "go" || !reflect.DeepEqual(api.Params, []string{"run", "./cmd/api"}) {
+		t.Errorf("Expected api to run ./cmd/api, got %s %v", api.Cmd, api.Params)
+	}
+	if gen := v.Jobs["generate"]; len(gen.Series) != 2 || gen.Series[1].Cmd != "buf" {
+		t.Errorf("Expected templ and buf generation steps, got %+v", gen.Series)
+	}
+}
+
+func TestDetectProject_Workspace(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.work"), []byte("go 1.25\n\nuse (\n\t./api // service\n\t./lib\n)\n"), 0644)
+	os.MkdirAll(filepath.Join(dir, "api"), 0755)
+	os.WriteFile(filepath.Join(dir, "api", "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "api", "main_test.go"), []byte("package main\n"), 0644)
+
+	p := detectProject(dir)
+	if !reflect.DeepEqual(p.workspace, []string{"api", "lib"}) || !reflect.DeepEqual(p.mains, []string{"api"}) {
+		t.Fatalf("Expected workspace modules and main, got %+v", p)
+	}
+	for _, j := range p.jobs() {
+		if j.name == "test" && len(j.steps) != 2 {
+			t.Errorf("Expected one test step per module, got %v", j.steps)
+		}
+	}
+}
+
+func TestDetectProject_NameClash(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		path := filepath.Join(dir, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("go.mod", "module example.com/site\n\ngo 1.25\n")
+	write("cmd/web/main.go", "package main\n")
+	write("cmd/generate/main.go", "package main\n")
+	write("views/home.templ", "")
+	write("package.json", `{"scripts": {"build": "vite build"}}`)
+
+	p := detectProject(dir)
+	var names []string
+	for _, j := range p.jobs() {
+		names = append(names, j.name)
+	}
+	if expected := []string{"generate", "generate-2", "web", "web-2"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected jobs %v, got %v", expected, names)
+	}
+
+	// No job overwrites another in the generated file
+	config := filepath.Join(dir, "vai.yml")
+	os.WriteFile(config, []byte(renderInit(p, p.jobs())), 0644)
+	v, err := fromFile(config)
+	if err != nil {
+		t.Fatalf("Failed to load the generated config: %v", err)
+	}
+	if len(v.Jobs) != 4 || v.Jobs["web"].Cmd != "go" || v.Jobs["web-2"].Cmd != "npm" {
+		t.Errorf("Expected the Go command and the frontend build as separate jobs, got %+v", v.Jobs)
+	}
+}
+
+func TestDetectProject_Empty(t *testing.T) {
+	jobs := detectProject(t.TempDir()).jobs()
+	if len(jobs) != 1 || jobs[0].name != "app" {
+		t.Errorf("Expected a template job, got %+v", jobs)
+	}
+}
+
+func TestConfirmJobs(t *testing.T) {
+	jobs := []initJob{
+		{name: "app", steps: [][]string{{"go", "run", "."}}},
+		{name: "test", steps: [][]string{{"go", "test", "./..."}}},
+		{name: "web", steps: [][]string{{"npm", "run", "build"}}},
+	}
+	var out bytes.Buffer
+	kept := confirmJobs(jobs, strings.NewReader("\nno\ny\n"), &out)
+
+	if len(kept) != 2 || kept[0].name != "app" || kept[1].name != "web" {
+		t.Errorf("Expected app and web to be kept, got %+v", kept)
+	}
+	if !strings.Contains(out.String(), "go test ./...") {
+		t.Errorf("Expected the prompt to show the commands, got %q", out.String())
+	}
+}
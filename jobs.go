@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"slices"
@@ -22,49 +24,140 @@ var (
 // parallelCtxKey is used to indicate parallel execution
 type parallelCtxKey struct{}
 
+// failFastCtxKey is used to stop a job at its first failing step
+type failFastCtxKey struct{}
+
+// failFast reports whether a job stops at its first failing step
+func failFast(ctx context.Context) bool {
+	f, _ := ctx.Value(failFastCtxKey{}).(bool)
+	return f
+}
+
+// dryRunCtxKey is used to print commands instead of running them
+type dryRunCtxKey struct{}
+
+// dryRun reports whether commands are only printed
+func dryRun(ctx context.Context) bool {
+	d, _ := ctx.Value(dryRunCtxKey{}).(bool)
+	return d
+}
+
 // Job is the unified struct for any unit of work
 type Job struct {
-	Name     string            `yaml:"-"`
-	Cmd      string            `yaml:"cmd,omitempty"`
-	Params   []string          `yaml:"params,omitempty"`
-	Series   []Job             `yaml:"series,omitempty"`
-	Parallel []Job             `yaml:"parallel,omitempty"`
-	Before   []Job             `yaml:"before,omitempty"`
-	After    []Job             `yaml:"after,omitempty"`
-	Env      map[string]string `yaml:"env,omitempty"`
-	Trigger  *Trigger          `yaml:"trigger,omitempty"`
+	Name       string            `yaml:"-"`
+	Cmd        string            `yaml:"cmd,omitempty"`
+	Params     []string          `yaml:"params,omitempty"`
+	Series     []Job             `yaml:"series,omitempty"`
+	Parallel   []Job             `yaml:"parallel,omitempty"`
+	Before     []Job             `yaml:"before,omitempty"`
+	After      []Job             `yaml:"after,omitempty"`
+	Env        map[string]string `yaml:"env,omitempty"`
+	Inputs     []string          `yaml:"inputs,omitempty"`
+	Outputs    []string          `yaml:"outputs,omitempty"`
+	Trigger    *Trigger          `yaml:"trigger,omitempty"`
+	RunOnStart StartMode         `yaml:"runOnStart,omitempty"`
+}
+
+// StartMode tells whether a job runs on start, on change or both
+type StartMode int
+
+const (
+	// StartAndChange runs the job on start and on every change
+	StartAndChange StartMode = iota
+	// ChangeOnly skips the run on start
+	ChangeOnly
+	// StartOnly runs the job once on start and never on change
+	StartOnly
+)
+
+// value returns the mode as written in vai.yml
+func (m StartMode) value() any {
+	switch m {
+	case ChangeOnly:
+		return false
+	case StartOnly:
+		return "only"
+	}
+	return true
+}
+
+// UnmarshalYAML parses true, false or only
+func (m *StartMode) UnmarshalYAML(node *yaml.Node) error {
+	var b bool
+	if node.Decode(&b) == nil {
+		*m = StartAndChange
+		if !b {
+			*m = ChangeOnly
+		}
+		return nil
+	}
+	if node.Value == "only" {
+		*m = StartOnly
+		return nil
+	}
+	return fmt.Errorf("invalid runOnStart '%s', expected true, false or only", node.Value)
 }
 
-// Trigger defines file paths and regex patterns to watch on
+// MarshalYAML writes the mode as true, false or only
+func (m StartMode) MarshalYAML() (any, error) {
+	return m.value(), nil
+}
+
+// Trigger defines file paths and regex or glob patterns to watch on
 type Trigger struct {
-	Paths []string `yaml:"paths,omitempty"`
-	Regex []string `yaml:"regex,omitempty"`
+	Paths      []string      `yaml:"paths,omitempty"`
+	Regex      []string      `yaml:"regex,omitempty"`
+	Glob       []string      `yaml:"glob,omitempty"`
+	Events     []string      `yaml:"events,omitempty"`
+	IgnoreSelf bool          `yaml:"ignoreSelf,omitempty"`
+	Cooldown   time.Duration `yaml:"cooldown,omitempty"`
+	Batch      time.Duration `yaml:"batch,omitempty"`
+	matcher    *matcher
+	roots      []root
 }
 
 // start handles the core execution
-func (j *Job) start(ctx context.Context) {
+func (j *Job) start(ctx context.Context) error {
+	var errs []error
+
 	// Execute 'Before' jobs
 	for _, beforeJob := range j.Before {
 		select {
 		case <-ctx.Done():
-			return
+			return ctx.Err()
 		default:
-			beforeJob.start(ctx)
+			if err := beforeJob.start(ctx); err != nil {
+				if failFast(ctx) {
+					return err
+				}
+				errs = append(errs, err)
+			}
 		}
 	}
 
 	// Execute
-	j.run(ctx)
+	if err := j.run(ctx); err != nil {
+		if failFast(ctx) {
+			return err
+		}
+		errs = append(errs, err)
+	}
 
 	// Execute 'After' jobs
 	for _, afterJob := range j.After {
 		select {
 		case <-ctx.Done():
-			return
+			return ctx.Err()
 		default:
-			afterJob.start(ctx)
+			if err := afterJob.start(ctx); err != nil {
+				if failFast(ctx) {
+					return err
+				}
+				errs = append(errs, err)
+			}
 		}
 	}
+	return errors.Join(errs...)
 }
 
 // stop stops a running command by its job name
@@ -101,22 +194,37 @@ func (j Job) stop() <-chan struct{} {
 }
 
 // run handles the core execution
-func (j *Job) run(ctx context.Context) {
+func (j *Job) run(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
-		return // Job was canceled
+		return ctx.Err() // Job was canceled
 	default:
 		// Continue
 	}
 
 	if j.Cmd != "" {
-		j.execute(ctx)
+		if j.upToDate() {
+			logger.log(SeverityWarn, OpSuccess, "Skipping up-to-date cmd: %s", green(j.Cmd, " ", j.Params))
+			return nil
+		}
+		err := j.execute(ctx)
+		if err == nil && !dryRun(ctx) {
+			j.saveFingerprint()
+		}
+		return err
 	} else if len(j.Series) > 0 {
+		var errs []error
 		for i := range j.Series {
 			seriesJob := &j.Series[i]
 			seriesJob.Name = j.Name
-			seriesJob.run(ctx)
+			if err := seriesJob.run(ctx); err != nil {
+				if failFast(ctx) {
+					return err
+				}
+				errs = append(errs, err)
+			}
 		}
+		return errors.Join(errs...)
 	} else if len(j.Parallel) > 0 {
 		var commandStrings []string
 		for _, pJob := range j.Parallel {
@@ -128,6 +236,12 @@ func (j *Job) run(ctx context.Context) {
 		}
 		logger.log(SeverityWarn, OpWarn, "Running cmds: %s", strings.Join(commandStrings, ", "))
 
+		// A failing step stops the others when failing fast
+		pCtx, cancel := context.WithCancel(context.WithValue(ctx, parallelCtxKey{}, true))
+		defer cancel()
+
+		var mu sync.Mutex
+		var errs []error
 		var wg sync.WaitGroup
 		for i := range j.Parallel {
 			jobToRun := j.Parallel[i]
@@ -135,16 +249,28 @@ func (j *Job) run(ctx context.Context) {
 			wg.Add(1)
 			go func(job Job) {
 				defer wg.Done()
-				pCtx := context.WithValue(ctx, parallelCtxKey{}, true)
-				job.run(pCtx)
+				if err := job.run(pCtx); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					if failFast(ctx) {
+						cancel()
+					}
+				}
 			}(jobToRun)
 		}
 		wg.Wait()
+		return errors.Join(errs...)
 	}
+	return nil
 }
 
 // execute executes the command and streams its output
-func (j *Job) execute(ctx context.Context) {
+func (j *Job) execute(ctx context.Context) error {
+	if dryRun(ctx) {
+		j.printDryRun(ctx)
+		return nil
+	}
 	if p, _ := ctx.Value(parallelCtxKey{}).(bool); !p {
 		logger.log(SeverityWarn, OpWarn, "Running cmd: %s", yellow(j.Cmd, " ", j.Params))
 	}
@@ -154,7 +280,7 @@ func (j *Job) execute(ctx context.Context) {
 		if ctx.Err() == nil {
 			logger.log(SeverityError, OpError, "%v", err)
 		}
-		return
+		return err
 	}
 
 	// Run and wait
@@ -163,7 +289,7 @@ func (j *Job) execute(ctx context.Context) {
 		if ctx.Err() == nil {
 			logger.log(SeverityError, OpError, "Failed to start cmd: %v", err)
 		}
-		return
+		return err
 	}
 	registerProcess(j.Name, cmd)
 	logger.log(SeverityDebug, OpWarn, "Executor: Started new process with PID: %d for job: %s", cmd.Process.Pid, j.Name)
@@ -200,6 +326,7 @@ func (j *Job) execute(ctx context.Context) {
 	} else {
 		logger.log(SeverityWarn, OpSuccess, "Cmd successfully: %s (%s)", green(cmdStr), cyan(duration.Round(time.Millisecond)))
 	}
+	return err
 }
 
 // UnmarshalYAML is the custom parser for the Action struct
@@ -217,15 +344,18 @@ func (j *Job) UnmarshalYAML(node *yaml.Node) error {
 
 	// Unmarshal it into a temporary struct to avoid recursion
 	var raw struct {
-		Name     string            `yaml:"name,omitempty"`
-		Cmd      string            `yaml:"cmd,omitempty"`
-		Params   []string          `yaml:"params,omitempty"`
-		Series   []Job             `yaml:"series,omitempty"`
-		Parallel []Job             `yaml:"parallel,omitempty"`
-		Before   []Job             `yaml:"before,omitempty"`
-		After    []Job             `yaml:"after,omitempty"`
-		Env      map[string]string `yaml:"env,omitempty"`
-		Trigger  *Trigger          `yaml:"trigger,omitempty"`
+		Name       string            `yaml:"name,omitempty"`
+		Cmd        string            `yaml:"cmd,omitempty"`
+		Params     []string          `yaml:"params,omitempty"`
+		Series     []Job             `yaml:"series,omitempty"`
+		Parallel   []Job             `yaml:"parallel,omitempty"`
+		Before     []Job             `yaml:"before,omitempty"`
+		After      []Job             `yaml:"after,omitempty"`
+		Env        map[string]string `yaml:"env,omitempty"`
+		Inputs     []string          `yaml:"inputs,omitempty"`
+		Outputs    []string          `yaml:"outputs,omitempty"`
+		Trigger    *Trigger          `yaml:"trigger,omitempty"`
+		RunOnStart StartMode         `yaml:"runOnStart,omitempty"`
 	}
 
 	if err := node.Decode(&raw); err != nil {
@@ -256,7 +386,10 @@ func (j *Job) UnmarshalYAML(node *yaml.Node) error {
 	j.Before = raw.Before
 	j.After = raw.After
 	j.Env = raw.Env
+	j.Inputs = raw.Inputs
+	j.Outputs = raw.Outputs
 	j.Trigger = raw.Trigger
+	j.RunOnStart = raw.RunOnStart
 
 	return nil
 }
@@ -266,10 +399,7 @@ func (j *Job) setupCmd(ctx context.Context) (*exec.Cmd, io.ReadCloser, io.ReadCl
 	cmd := exec.CommandContext(ctx, j.Cmd, j.Params...)
 
 	// Set up environment variables
-	cmd.Env = os.Environ()
-	for key, val := range j.Env {
-		cmd.Env = append(cmd.Env, key+"="+val)
-	}
+	cmd.Env = append(os.Environ(), j.environ(ctx)...)
 
 	// Set the process group ID
 	setpgid(cmd)
@@ -286,6 +416,39 @@ func (j *Job) setupCmd(ctx context.Context) (*exec.Cmd, io.ReadCloser, io.ReadCl
 	return cmd, stdoutPipe, stderrPipe, nil
 }
 
+// environ returns the variables a command gets on top of the vai environment
+func (j *Job) environ(ctx context.Context) []string {
+	var env []string
+	for _, key := range slices.Sorted(maps.Keys(j.Env)) {
+		env = append(env, key+"="+j.Env[key])
+	}
+	if files, ok := ctx.Value(changedFilesCtxKey{}).([]string); ok {
+		env = append(env, "VAI_CHANGED_FILES="+strings.Join(files, string(os.PathListSeparator)))
+	}
+	return env
+}
+
+// printDryRun prints the resolved command a step would run
+func (j *Job) printDryRun(ctx context.Context) {
+	path, err := exec.LookPath(j.Cmd)
+	if err != nil {
+		path = j.Cmd + " (not found)"
+	}
+	dir, _ := os.Getwd()
+	// Before and after steps have no job name
+	label := "Dry run:"
+	if j.Name != "" {
+		label = "Dry run " + j.Name + ":"
+	}
+	// Printed whatever the severity, it is the output of a dry run
+	fmt.Println(cyan(label), yellow(commandString(*j)))
+	fmt.Println("  ", white("binary:"), path)
+	fmt.Println("  ", white("dir:"), dir)
+	for _, e := range j.environ(ctx) {
+		fmt.Println("  ", white("env:"), e)
+	}
+}
+
 // cleanupProcess removes a process from the running list
 func cleanupProcess(jobName string, cmd *exec.Cmd) {
 	if jobName != "" {
@@ -328,8 +491,9 @@ func streamOutput(reader io.Reader, writer io.Writer) {
 
 // instance contains a job execution
 type instance struct {
-	cancel context.CancelFunc
-	id     uint64
+	cancel  context.CancelFunc
+	id      uint64
+	started time.Time
 }
 
 // Manager tracks running jobs
@@ -363,6 +527,29 @@ func (m *Manager) stop() {
 	}
 }
 
+// stopJob stops a running job and waits for its processes to exit
+func (m *Manager) stopJob(jobName string) {
+	m.mu.Lock()
+	job, ok := m.running[jobName]
+	m.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	logger.log(SeverityDebug, OpWarn, "JobManager: Stopping job: %s", jobName)
+	job.cancel()
+	<-(&Job{Name: jobName}).stop()
+}
+
+// runningSince returns when a job was started if it is still running
+func (m *Manager) runningSince(jobName string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, ok := m.running[jobName]
+	return job.started, ok
+}
+
 // register starts tracking a new job. If a job with the same name is already running, it cancels the previous
 func (m *Manager) register(jobName string) (context.Context, func()) {
 	// Check if a job is already running
@@ -392,8 +579,9 @@ func (m *Manager) register(jobName string) (context.Context, func()) {
 	id := m.nextID
 
 	m.running[jobName] = instance{
-		cancel: cancel,
-		id:     id,
+		cancel:  cancel,
+		id:      id,
+		started: time.Now(),
 	}
 
 	// Return a function that will deregister the job
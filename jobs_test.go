@@ -137,6 +137,27 @@ func TestFromCLI(t *testing.T) {
 		}
 	})
 
+	t.Run("Glob replaces default regex", func(t *testing.T) {
+		args := &Args{
+			PositionalArgs: []string{"go", "run", "."},
+			Glob:           "**/*.go,web/**/*.{html,css}",
+		}
+
+		vai, err := newVai(args)
+		if err != nil {
+			t.Fatalf("newVai failed: %v", err)
+		}
+
+		job := vai.Jobs["default"]
+		if len(job.Trigger.Regex) != 0 {
+			t.Errorf("Expected no regex patterns, got '%v'", job.Trigger.Regex)
+		}
+		expected := []string{"**/*.go", "web/**/*.{html,css}"}
+		if !reflect.DeepEqual(job.Trigger.Glob, expected) {
+			t.Errorf("Expected glob patterns '%v', got '%v'", expected, job.Trigger.Glob)
+		}
+	})
+
 	t.Run("From multiple cmd flags", func(t *testing.T) {
 		// seriesCmds := []string{"go fmt ./...", "go run ."}
 		args := &Args{
@@ -198,6 +219,24 @@ jobs:
 		}
 	})
 
+	t.Run("Return error for unknown trigger event", func(t *testing.T) {
+		yamlContent := `
+jobs:
+  migrations:
+    trigger:
+      events: [create, touch]
+    cmd: "go run ./migrate"
+`
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "vai.yml")
+		os.WriteFile(filePath, []byte(yamlContent), 0644)
+
+		_, err := fromFile(filePath)
+		if err == nil || !strings.Contains(err.Error(), "touch") {
+			t.Fatalf("Expected an error for unknown event 'touch', got %v", err)
+		}
+	})
+
 	t.Run("Return error for malformed YAML", func(t *testing.T) {
 		yamlContent := `config: { path: "/app }`
 		tempDir := t.TempDir()
@@ -513,3 +552,64 @@ func TestExecute(t *testing.T) {
 		}
 	})
 }
+
+func TestStart_FailFast(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping executor tests on Windows due to shell command differences")
+	}
+	resetGlobals()
+	dir := t.TempDir()
+	next := dir + "/next"
+	job := Job{
+		Series: []Job{
+			{Cmd: "false"},
+			{Cmd: "touch", Params: []string{next}},
+		},
+	}
+
+	// Without fail-fast every step runs and the error is reported
+	if err := job.start(context.Background()); err == nil {
+		t.Error("Expected the failing step to be reported")
+	}
+	if _, err := os.Stat(next); err != nil {
+		t.Error("Expected the next step to run in watch mode")
+	}
+	os.Remove(next)
+
+	ctx := context.WithValue(context.Background(), failFastCtxKey{}, true)
+	if err := job.start(ctx); err == nil {
+		t.Error("Expected the failing step to be reported")
+	}
+	if _, err := os.Stat(next); err == nil {
+		t.Error("Expected the next step to be skipped when failing fast")
+	}
+}
+
+func TestStart_DryRun(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	out := dir + "/out"
+	job := Job{
+		Name: "build",
+		Series: []Job{
+			{Cmd: "touch", Params: []string{out}, Env: map[string]string{"PORT": "8080"}},
+			{Cmd: "touch", Params: []string{out}, Env: map[string]string{"PORT": "9090"}},
+		},
+	}
+
+	ctx := context.WithValue(context.Background(), dryRunCtxKey{}, true)
+	ctx = context.WithValue(ctx, changedFilesCtxKey{}, []string{"main.go"})
+	printed := captureOutput(func() {
+		if err := job.start(ctx); err != nil {
+			t.Errorf("Expected a dry run not to fail, got %v", err)
+		}
+	})
+	if _, err := os.Stat(out); err == nil {
+		t.Error("Expected a dry run not to run the command")
+	}
+	for _, expected := range []string{"touch " + out, "PORT=8080", "PORT=9090", "VAI_CHANGED_FILES=main.go"} {
+		if !strings.Contains(printed, expected) {
+			t.Errorf("Expected the dry run to print %q, got:\n%s", expected, printed)
+		}
+	}
+}
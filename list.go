@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// jobInfo is a job as printed by vai list --json
+type jobInfo struct {
+	Name       string       `json:"name"`
+	Enabled    bool         `json:"enabled"`
+	RunOnStart any          `json:"runOnStart"`
+	Trigger    *triggerInfo `json:"trigger,omitempty"`
+	stepInfo
+}
+
+// triggerInfo is the trigger of a job as printed by vai list --json
+type triggerInfo struct {
+	Paths  []string `json:"paths"`
+	Regex  []string `json:"regex,omitempty"`
+	Glob   []string `json:"glob,omitempty"`
+	Events []string `json:"events,omitempty"`
+}
+
+// stepInfo is a step of a job as printed by vai list --json
+type stepInfo struct {
+	Cmd      string            `json:"cmd,omitempty"`
+	Before   []stepInfo        `json:"before,omitempty"`
+	Series   []stepInfo        `json:"series,omitempty"`
+	Parallel []stepInfo        `json:"parallel,omitempty"`
+	After    []stepInfo        `json:"after,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+}
+
+// explainInfo is the outcome of vai explain --json
+type explainInfo struct {
+	Path    string       `json:"path"`
+	Ignored bool         `json:"ignored,omitempty"`
+	Jobs    []explainJob `json:"jobs"`
+}
+
+// explainJob tells whether a job fires on a changed file and why
+type explainJob struct {
+	Name    string `json:"name"`
+	Fires   bool   `json:"fires"`
+	Pattern string `json:"pattern,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// newStepInfo converts a job and its nested steps
+func newStepInfo(j Job) stepInfo {
+	s := stepInfo{Env: j.Env}
+	if j.Cmd != "" {
+		s.Cmd = commandString(j)
+	}
+	for _, b := range j.Before {
+		s.Before = append(s.Before, newStepInfo(b))
+	}
+	for _, step := range j.Series {
+		s.Series = append(s.Series, newStepInfo(step))
+	}
+	for _, p := range j.Parallel {
+		s.Parallel = append(s.Parallel, newStepInfo(p))
+	}
+	for _, a := range j.After {
+		s.After = append(s.After, newStepInfo(a))
+	}
+	return s
+}
+
+// loadJobs loads the config for the list and explain commands
+func loadJobs(args *Args) (*Vai, bool) {
+	if !fileExists(args.ConfigFile) {
+		logger.log(SeverityError, OpError, "No config file %s to read jobs from", args.ConfigFile)
+		return nil, false
+	}
+
+	// Logs share stdout, keep the JSON clean of warnings
+	if args.JSON {
+		logger.setLevel(SeverityError)
+	}
+	v, err := newVai(args)
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to initialize Vai: %v", err)
+		return nil, false
+	}
+	return v, true
+}
+
+// printJSON writes a value as indented JSON
+func printJSON(value any) int {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to encode JSON: %v", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
+
+// runList prints every job with its trigger and steps
+func runList(args *Args) int {
+	v, ok := loadJobs(args)
+	if !ok {
+		return 1
+	}
+
+	// Show the trigger paths as they are written in the config
+	v.relativePaths()
+	names := slices.Sorted(maps.Keys(v.Jobs))
+
+	if args.JSON {
+		jobs := []jobInfo{}
+		for _, name := range names {
+			job := v.Jobs[name]
+			info := jobInfo{Name: name, Enabled: watches(job), RunOnStart: job.RunOnStart.value(), stepInfo: newStepInfo(job)}
+			if job.Trigger != nil {
+				info.Trigger = &triggerInfo{
+					Paths:  job.Trigger.Paths,
+					Regex:  job.Trigger.Regex,
+					Glob:   job.Trigger.Glob,
+					Events: job.Trigger.Events,
+				}
+			}
+			jobs = append(jobs, info)
+		}
+		return printJSON(jobs)
+	}
+
+	for _, name := range names {
+		job := v.Jobs[name]
+		printJob(name, job)
+		switch {
+		case job.RunOnStart == StartOnly:
+			fmt.Println("  ", cyan("- State:"), yellow("runs on start only"))
+		case job.Trigger == nil:
+			fmt.Println("  ", cyan("- State:"), yellow("runs on start only, no trigger"))
+		case job.RunOnStart == ChangeOnly:
+			fmt.Println("  ", cyan("- State:"), green("watching"), "no run on start")
+		default:
+			fmt.Println("  ", cyan("- State:"), green("watching"))
+		}
+	}
+	return 0
+}
+
+// watches reports whether a job runs on file changes
+func watches(job Job) bool {
+	return job.Trigger != nil && job.RunOnStart != StartOnly
+}
+
+// runExplain shows which jobs a change of a file would trigger and why the others would not
+func runExplain(args *Args) int {
+	if args.Input == "" {
+		logger.log(SeverityError, OpError, "missing file, use vai explain <file>")
+		return 1
+	}
+	v, ok := loadJobs(args)
+	if !ok {
+		return 1
+	}
+
+	path := args.Input
+	abs, canonical := resolvePath(path)
+	info := explainInfo{Path: path, Jobs: []explainJob{}}
+
+	// Ignored files never reach the jobs
+	ignore := loadIgnorer(v.watchPaths(), v.Config.RespectGitignore == nil || *v.Config.RespectGitignore)
+	if ignore.ignored(abs, isDir(abs)) {
+		info.Ignored = true
+	}
+
+	event := fswatcher.WatchEvent{Path: path, Types: []fswatcher.EventType{fswatcher.EventMod}}
+	for _, name := range slices.Sorted(maps.Keys(v.Jobs)) {
+		job := v.Jobs[name]
+		m := matchJob(job, event, abs, canonical)
+		e := explainJob{Name: name, Fires: m.ok && !info.Ignored, Pattern: m.pattern}
+		switch {
+		case info.Ignored:
+			e.Reason = "ignored by an ignore file"
+		case !m.ok:
+			e.Reason = explainReason(job, m)
+		}
+		info.Jobs = append(info.Jobs, e)
+	}
+
+	if args.JSON {
+		return printJSON(info)
+	}
+
+	fmt.Println(cyan("Changed file:"), path)
+	if info.Ignored {
+		fmt.Println(yellow("The file is ignored by .gitignore, .git/info/exclude or .vaiignore, no job fires"))
+	}
+	for _, e := range info.Jobs {
+		switch {
+		case e.Fires && e.Pattern != "":
+			fmt.Println(green("  ✓ ", e.Name), "matched by", e.Pattern)
+		case e.Fires:
+			fmt.Println(green("  ✓ ", e.Name), "no pattern, every file in its paths matches")
+		default:
+			fmt.Println(red("  ✗ ", e.Name), e.Reason)
+		}
+	}
+	return 0
+}
+
+// explainReason describes why a job is skipped in terms of its config
+func explainReason(job Job, m jobMatch) string {
+	switch m.kind {
+	case "no trigger":
+		return "no trigger, runs on start only"
+	case "start only":
+		return "runOnStart: only, never runs on change"
+	case "path mismatch":
+		paths := slices.Clone(job.Trigger.Paths)
+		cwd, _ := os.Getwd()
+		for i, p := range paths {
+			if rel, err := filepath.Rel(cwd, p); err == nil && filepath.IsAbs(p) {
+				paths[i] = filepath.ToSlash(rel)
+			}
+		}
+		return fmt.Sprintf("path mismatch, not under %v", paths)
+	case "excluded":
+		return fmt.Sprintf("excluded by '%s'", m.pattern)
+	case "no inclusion match":
+		var include []string
+		for _, p := range append(slices.Clone(job.Trigger.Regex), job.Trigger.Glob...) {
+			if !strings.HasPrefix(p, "!") {
+				include = append(include, p)
+			}
+		}
+		return fmt.Sprintf("no inclusion match for '%s' in %v", m.relPath, include)
+	case "event mismatch":
+		return fmt.Sprintf("event mismatch, only fires on %v", job.Trigger.Events)
+	}
+	return m.reason
+}
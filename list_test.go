@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+const listConfig = `jobs:
+  api:
+    trigger:
+      glob: ["**/*.go", "!**/*_test.go"]
+    cmd: go
+    params: [run, .]
+  css:
+    trigger:
+      glob: ["**/*.css"]
+      events: [create]
+    cmd: sass
+  docs:
+    trigger:
+      paths: [docs]
+    cmd: mkdocs
+  setup:
+    series:
+      - cmd: go
+        params: [mod, download]
+`
+
+func TestRunList(t *testing.T) {
+	resetGlobals()
+	t.Chdir(t.TempDir())
+	os.Mkdir("docs", 0755)
+	os.WriteFile("vai.yml", []byte(listConfig), 0644)
+
+	out := captureOutput(func() {
+		if code := runList(mustParseArgs(t, []string{"list"})); code != 0 {
+			t.Errorf("Expected exit code 0, got %d", code)
+		}
+	})
+	for _, expected := range []string{"api", "!**/*_test.go", "go run .", "go mod download", "runs on start only"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected the list to contain %q, got:\n%s", expected, out)
+		}
+	}
+
+	// Debug logs while loading must not end up in the JSON
+	logger = newLogger(SeverityDebug)
+	defer func() { logger = newLogger(SeverityError) }()
+	out = captureOutput(func() {
+		runList(mustParseArgs(t, []string{"list", "--json"}))
+	})
+	var jobs []jobInfo
+	if err := json.Unmarshal([]byte(out), &jobs); err != nil {
+		t.Fatalf("Expected JSON, got %v:\n%s", err, out)
+	}
+	if len(jobs) != 4 || jobs[0].Name != "api" || jobs[0].Cmd != "go run ." || jobs[0].Trigger.Paths[0] != "." {
+		t.Errorf("Unexpected jobs %+v", jobs)
+	}
+	if jobs[3].Enabled || len(jobs[3].Series) != 1 {
+		t.Errorf("Expected setup to run on start only, got %+v", jobs[3])
+	}
+}
+
+func TestRunExplain(t *testing.T) {
+	resetGlobals()
+	t.Chdir(t.TempDir())
+	os.Mkdir("docs", 0755)
+	os.WriteFile("vai.yml", []byte(listConfig), 0644)
+
+	explain := func(path string) map[string]explainJob {
+		out := captureOutput(func() {
+			if code := runExplain(mustParseArgs(t, []string{"explain", "--json", path})); code != 0 {
+				t.Errorf("Expected exit code 0, got %d", code)
+			}
+		})
+		var info explainInfo
+		if err := json.Unmarshal([]byte(out), &info); err != nil {
+			t.Fatalf("Expected JSON, got %v:\n%s", err, out)
+		}
+		jobs := make(map[string]explainJob)
+		for _, j := range info.Jobs {
+			jobs[j.Name] = j
+		}
+		return jobs
+	}
+
+	jobs := explain("internal/server.go")
+	if !jobs["api"].Fires || jobs["api"].Pattern != "**/*.go" {
+		t.Errorf("Expected api to fire on **/*.go, got %+v", jobs["api"])
+	}
+	if !strings.HasPrefix(jobs["docs"].Reason, "path mismatch") {
+		t.Errorf("Expected a path mismatch for docs, got %+v", jobs["docs"])
+	}
+	if !strings.HasPrefix(jobs["css"].Reason, "no inclusion match") {
+		t.Errorf("Expected no inclusion match for css, got %+v", jobs["css"])
+	}
+	if !strings.HasPrefix(jobs["setup"].Reason, "no trigger") {
+		t.Errorf("Expected setup to have no trigger, got %+v", jobs["setup"])
+	}
+
+	jobs = explain("internal/server_test.go")
+	if jobs["api"].Fires || jobs["api"].Reason != "excluded by '!**/*_test.go'" {
+		t.Errorf("Expected api to be excluded, got %+v", jobs["api"])
+	}
+
+	jobs = explain("web/site.css")
+	if jobs["css"].Fires || !strings.HasPrefix(jobs["css"].Reason, "event mismatch") {
+		t.Errorf("Expected css to fire on create only, got %+v", jobs["css"])
+	}
+	if !explain("docs/index.md")["docs"].Fires {
+		t.Error("Expected docs to fire on its path")
+	}
+
+	var code int
+	out := captureOutput(func() { code = runExplain(mustParseArgs(t, []string{"explain"})) })
+	if code != 1 || !strings.Contains(out, "missing file") {
+		t.Errorf("Expected a usage error without a file, got exit code %d: %s", code, out)
+	}
+}
+
+func TestParseArgs_List(t *testing.T) {
+	cli := mustParseArgs(t, []string{"explain", "--json", "main.go"})
+	if cli.Command != "explain" || !cli.JSON || cli.Input != "main.go" {
+		t.Errorf("Expected to explain main.go as JSON, got %+v", cli)
+	}
+	if _, err := parseArgs([]string{"--json", "go", "run", "."}); err == nil {
+		t.Error("Expected --json to be rejected outside list and explain")
+	}
+}
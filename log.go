@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -130,18 +131,25 @@ var opColor = map[Op]string{
 
 // Logger represents a logger instance with the specified log level
 type Logger struct {
-	level Severity
+	level atomic.Int32
 }
 
 // newLogger creates a new logger instance with the specified log level
 func newLogger(level Severity) *Logger {
-	return &Logger{level: level}
+	l := &Logger{}
+	l.setLevel(level)
+	return l
+}
+
+// setLevel changes the log level, safe while other goroutines are logging
+func (l *Logger) setLevel(level Severity) {
+	l.level.Store(int32(level))
 }
 
 // log logs a message with the specified level, operation, format, and arguments
 func (l *Logger) log(level Severity, op Op, format string, args ...any) {
 	// Severity filter
-	if level < l.level {
+	if level < Severity(l.level.Load()) {
 		return
 	}
 
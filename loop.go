@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// selfTriggerWindow is how soon after a job starts an event may be caused by the job itself
+const selfTriggerWindow = 2 * time.Second
+
+// loopState tracks self-triggered restarts of a job
+type loopState struct {
+	restarts  int
+	paused    bool
+	lastEvent time.Time
+	lastPaths []string
+}
+
+// loopGuard detects jobs that keep re-triggering themselves
+type loopGuard struct {
+	mu   sync.Mutex
+	jobs map[string]*loopState
+}
+
+// newLoopGuard creates an empty loop guard
+func newLoopGuard() *loopGuard {
+	return &loopGuard{jobs: make(map[string]*loopState)}
+}
+
+// allowTrigger decides whether the changed paths may (re)start a job
+func (v *Vai) allowTrigger(name string, job Job, paths []string) bool {
+	started, running := v.manager.runningSince(name)
+	if running && job.Trigger != nil && job.Trigger.IgnoreSelf {
+		logger.log(SeverityDebug, OpWarn, "Skipping job '%s': event arrived while its steps are running", name)
+		return false
+	}
+	// Without a guard, as built outside of newVai, jobs are never paused
+	if v.guard == nil {
+		return true
+	}
+	v.guard.mu.Lock()
+	defer v.guard.mu.Unlock()
+
+	st, ok := v.guard.jobs[name]
+	if !ok {
+		st = &loopState{}
+		v.guard.jobs[name] = st
+	}
+
+	now := time.Now()
+
+	// A paused job resumes once its trigger has been quiet for a while
+	if st.paused {
+		if now.Sub(st.lastEvent) < selfTriggerWindow {
+			st.lastEvent = now
+			logger.log(SeverityDebug, OpWarn, "Skipping job '%s': paused after repeated self-triggered restarts", name)
+			return false
+		}
+		st.paused = false
+		st.restarts = 0
+		logger.log(SeverityWarn, OpInfo, "Job '%s' resumed", name)
+	}
+	st.lastEvent = now
+
+	// Self-caused events change the job outputs, or again the files that started the running job
+	self := running && now.Sub(started) < selfTriggerWindow && writtenByJob(job, paths, st.lastPaths)
+	st.lastPaths = paths
+	if !self {
+		st.restarts = 0
+		return true
+	}
+
+	st.restarts++
+	if v.Config.MaxSelfRestarts > 0 && st.restarts >= v.Config.MaxSelfRestarts {
+		st.paused = true
+		logger.log(SeverityWarn, OpError, "Job '%s' restarted itself %d times in a row, pausing it: its steps probably write files matching its own trigger (see trigger.ignoreSelf)", name, st.restarts)
+		return false
+	}
+	return true
+}
+
+// writtenByJob reports whether changed paths are outputs of the job or the ones that started its current run
+func writtenByJob(job Job, paths, lastPaths []string) bool {
+	outputs := jobOutputs(job)
+	for _, p := range paths {
+		abs, _ := filepath.Abs(p)
+		if slices.Contains(outputs, abs) || slices.Contains(lastPaths, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// jobOutputs collects the absolute outputs of a job and its steps
+func jobOutputs(job Job) []string {
+	var outputs []string
+	for _, out := range job.Outputs {
+		if abs, err := filepath.Abs(out); err == nil {
+			outputs = append(outputs, abs)
+		}
+	}
+	for _, steps := range [][]Job{job.Before, job.Series, job.Parallel, job.After} {
+		for _, step := range steps {
+			outputs = append(outputs, jobOutputs(step)...)
+		}
+	}
+	return outputs
+}
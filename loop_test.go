@@ -0,0 +1,89 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAllowTrigger(t *testing.T) {
+	t.Run("allows jobs that are not running", func(t *testing.T) {
+		v := &Vai{manager: newManager(), guard: newLoopGuard(), Config: Config{MaxSelfRestarts: 2}}
+		job := Job{Trigger: &Trigger{}}
+		for range 5 {
+			if !v.allowTrigger("app", job, []string{"main.go"}) {
+				t.Fatal("Expected an idle job to be triggered")
+			}
+		}
+	})
+
+	t.Run("ignores events while running with ignoreSelf", func(t *testing.T) {
+		v := &Vai{manager: newManager(), guard: newLoopGuard()}
+		_, deregister := v.manager.register("gen")
+		defer deregister()
+
+		job := Job{Trigger: &Trigger{IgnoreSelf: true}}
+		if v.allowTrigger("gen", job, []string{"main.go"}) {
+			t.Error("Expected event to be ignored while the job is running")
+		}
+	})
+
+	t.Run("pauses a job that keeps restarting itself", func(t *testing.T) {
+		v := &Vai{manager: newManager(), guard: newLoopGuard(), Config: Config{MaxSelfRestarts: 3}}
+		job := Job{Trigger: &Trigger{}}
+
+		// The job rewrites the file that started it on every run
+		allowed := 0
+		for range 6 {
+			_, deregister := v.manager.register("gen")
+			if v.allowTrigger("gen", job, []string{"gen.go"}) {
+				allowed++
+			}
+			deregister()
+		}
+		if allowed != 3 {
+			t.Errorf("Expected 3 restarts before pausing, got %d", allowed)
+		}
+		if !v.guard.jobs["gen"].paused {
+			t.Fatal("Expected the job to be paused")
+		}
+
+		// Resumes once the trigger has been quiet
+		v.guard.jobs["gen"].lastEvent = time.Now().Add(-selfTriggerWindow)
+		if !v.allowTrigger("gen", job, []string{"gen.go"}) {
+			t.Error("Expected the job to resume after a quiet period")
+		}
+	})
+
+	t.Run("pauses at once on writes to the job outputs", func(t *testing.T) {
+		v := &Vai{manager: newManager(), guard: newLoopGuard(), Config: Config{MaxSelfRestarts: 2}}
+		job := Job{Trigger: &Trigger{}, Series: []Job{{Cmd: "protoc", Outputs: []string{"api.pb.go"}}}}
+		out, _ := filepath.Abs("api.pb.go")
+
+		allowed := 0
+		for range 3 {
+			_, deregister := v.manager.register("proto")
+			if v.allowTrigger("proto", job, []string{out}) {
+				allowed++
+			}
+			deregister()
+		}
+		if allowed != 1 {
+			t.Errorf("Expected 1 restart before pausing, got %d", allowed)
+		}
+	})
+
+	t.Run("doesn't count user edits of different files", func(t *testing.T) {
+		v := &Vai{manager: newManager(), guard: newLoopGuard(), Config: Config{MaxSelfRestarts: 2}}
+		job := Job{Trigger: &Trigger{}}
+
+		// Quick saves of different files right after each restart
+		for _, file := range []string{"a.go", "b.go", "c.go", "d.go"} {
+			_, deregister := v.manager.register("app")
+			if !v.allowTrigger("app", job, []string{file}) {
+				t.Fatalf("Expected the save of %s to restart the job", file)
+			}
+			deregister()
+		}
+	})
+}
@@ -3,8 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -15,15 +18,25 @@ var logger *Logger
 
 // Args struct for command line arguments
 type Args struct {
+	Command        string
 	CmdFlags       []string
 	PositionalArgs []string
 	Path           string
 	Regex          string
+	Glob           string
 	Env            string
 	ConfigFile     string
+	Input          string
+	Shell          string
+	Jobs           []string
 	SaveFile       string
 	Help           bool
 	Debug          bool
+	Interactive    bool
+	JSON           bool
+	DryRun         bool
+	NoInitialRun   bool
+	Force          bool
 	Version        bool
 	Save           bool
 }
@@ -32,14 +45,49 @@ type Args struct {
 func main() {
 	args := os.Args[1:]
 
-	cli := parseArgs(args)
+	logger = newLogger(SeverityWarn)
+	cli, err := parseArgs(args)
+	if err != nil {
+		logger.log(SeverityError, OpError, "%v", err)
+		fmt.Fprintln(os.Stderr, "Run 'vai --help' for usage")
+		os.Exit(2)
+	}
+	if cli.Help {
+		printHelp()
+		os.Exit(0)
+	}
 
 	// Set severity level based on debug flag
-	bootstrapSeverity := SeverityWarn
 	if cli.Debug {
-		bootstrapSeverity = SeverityDebug
+		logger.setLevel(SeverityDebug)
+	}
+
+	// Run subcommands
+	switch cli.Command {
+	case "run", "once":
+		os.Exit(runOnce(cli))
+	case "list":
+		os.Exit(runList(cli))
+	case "explain":
+		os.Exit(runExplain(cli))
+	case "validate":
+		os.Exit(runValidate(cli.ConfigFile))
+	case "schema":
+		os.Exit(runSchema())
+	case "init":
+		os.Exit(runInit(cli, os.Stdin))
+	case "migrate":
+		os.Exit(runMigrate(cli))
+	case "completion":
+		os.Exit(runCompletion(cli.Shell))
+	case "__complete":
+		os.Exit(runComplete(cli))
+	}
+
+	// Write the configuration instead of running it
+	if cli.Save {
+		os.Exit(runSave(cli))
 	}
-	logger = newLogger(bootstrapSeverity)
 
 	// Print startup message
 	fmt.Print(purple("\n--------------\n"))
@@ -60,7 +108,7 @@ func main() {
 	}
 
 	// Initialize logger based on vai config and context
-	logger = newLogger(parseSeverity(v.Config.Severity))
+	logger.setLevel(parseSeverity(v.Config.Severity))
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -92,164 +140,293 @@ func main() {
 	wg.Wait()
 	logger.log(SeverityInfo, OpWarn, "Shutting down...")
 	v.manager.stop()
+}
 
-	// Save configuration if requested
-	if cli.Save {
-		logger.log(SeverityInfo, OpWarn, "Saving configuration to %s...", cli.SaveFile)
-		if err := v.save(cli.SaveFile); err != nil {
-			logger.log(SeverityError, OpError, "Failed to save config file: %v", err)
+// hasCmd reports whether a command was given on the command line
+func (c *Args) hasCmd() bool {
+	return len(c.CmdFlags) > 0 || len(c.PositionalArgs) > 0
+}
+
+// cliFlag describes a flag, its short form and the commands accepting it
+type cliFlag struct {
+	name     string
+	short    string
+	usage    string
+	value    bool
+	optional bool
+	commands []string
+	set      func(c *Args, value string) error
+	// env parses the VAI_ variable instead of set, for flags where the two differ
+	env func(c *Args, value string) error
+}
+
+// runMode is the command name of watching and running jobs
+const runMode = ""
+
+// cliFlags are the flags of vai, each one can also be set with VAI_<NAME>
+var cliFlags = []cliFlag{
+	{name: "cmd", short: "c", usage: "Command to run", value: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		c.CmdFlags = append(c.CmdFlags, v)
+		return nil
+	}},
+	{name: "path", short: "p", usage: "Path to watch", value: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		c.Path = v
+		return nil
+	}},
+	{name: "regex", short: "r", usage: "Regex patterns to watch", value: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		c.Regex = v
+		return nil
+	}},
+	{name: "glob", short: "g", usage: "Glob patterns to watch", value: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		c.Glob = v
+		return nil
+	}},
+	{name: "env", short: "e", usage: "KEY=VALUE environment variables", value: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		c.Env = v
+		return nil
+	}},
+	{name: "config", usage: "Config file to use", value: true, set: func(c *Args, v string) error {
+		c.ConfigFile = v
+		return nil
+	}},
+	{name: "save", short: "s", usage: "Write the config and exit", optional: true, commands: []string{runMode}, set: func(c *Args, v string) error {
+		// --save takes an optional file, only attached with = to keep it apart from the command, any value is a path
+		c.Save = true
+		if v != "" {
+			c.SaveFile = v
 		}
-		logger.log(SeverityInfo, OpSuccess, "Configuration saved successfully")
-	}
+		return nil
+	}, env: func(c *Args, v string) error {
+		// VAI_SAVE=true can't be told apart from a path, so booleans toggle saving to the default file
+		if b, err := strconv.ParseBool(v); err == nil {
+			c.Save = b
+			return nil
+		}
+		c.Save = true
+		c.SaveFile = v
+		return nil
+	}},
+	{name: "force", short: "f", usage: "Overwrite or update an existing file", commands: []string{runMode, "init", "migrate"}, set: func(c *Args, v string) error {
+		return boolFlag(&c.Force, v)
+	}},
+	{name: "interactive", short: "i", usage: "Pick the jobs to add", commands: []string{"init"}, set: func(c *Args, v string) error {
+		return boolFlag(&c.Interactive, v)
+	}},
+	{name: "dry-run", usage: "Print the commands instead of running them", commands: []string{runMode, "run", "once"}, set: func(c *Args, v string) error {
+		return boolFlag(&c.DryRun, v)
+	}},
+	{name: "no-initial-run", usage: "Wait for a change before running jobs", commands: []string{runMode}, set: func(c *Args, v string) error {
+		return boolFlag(&c.NoInitialRun, v)
+	}},
+	{name: "json", usage: "Print as JSON", commands: []string{"list", "explain"}, set: func(c *Args, v string) error {
+		return boolFlag(&c.JSON, v)
+	}},
+	{name: "debug", short: "d", usage: "Show debug logs", set: func(c *Args, v string) error {
+		return boolFlag(&c.Debug, v)
+	}},
+	{name: "version", short: "v", usage: "Print the version", commands: []string{runMode}, set: func(c *Args, v string) error {
+		return boolFlag(&c.Version, v)
+	}},
+	{name: "help", short: "h", usage: "Show the help", set: func(c *Args, v string) error {
+		return boolFlag(&c.Help, v)
+	}},
 }
 
-// handleCmd parses the command flag
-func (c *Args) handleCmd(attachedValue string, args []string, currentIndex int, knownFlagsWithArg, knownBoolFlags map[string]bool, shortFlags map[string]string) int {
-	if attachedValue != "" {
-		c.CmdFlags = append(c.CmdFlags, attachedValue)
-		return currentIndex
-	}
-	var cmd string
-	var newIndex int
-	cmd, newIndex = parseCmdFlag(args, currentIndex, knownFlagsWithArg, knownBoolFlags, shortFlags)
-	if cmd != "" {
-		c.CmdFlags = append(c.CmdFlags, cmd)
-	}
-	return newIndex
+// subcommand is a command of vai and the kind of argument it takes
+type subcommand struct {
+	usage string
+	arg   string
+	many  bool
+	// flag is the flag the argument stands for, which its VAI_ variable can't override
+	flag string
+	set  func(c *Args, arg string)
 }
 
-// handleArgFlag parses flags with arguments
-func (c *Args) handleArgFlag(flagName, attachedValue string, args []string, currentIndex int) int {
-	var value string
-	newIndex := currentIndex
-	if attachedValue != "" {
-		value = attachedValue
-	} else {
-		value, newIndex = parseValueFlag(args, currentIndex)
-	}
-	switch flagName {
-	case "regex":
-		c.Regex = value
-	case "env":
-		c.Env = value
-	case "path":
-		c.Path = value
-	}
-	return newIndex
+// subcommands are the commands of vai, the ones starting with __ are used by the completion scripts
+var subcommands = map[string]subcommand{
+	"run":        {usage: "Run jobs once without watching", arg: "job", many: true, set: func(c *Args, arg string) { c.Jobs = append(c.Jobs, arg) }},
+	"once":       {usage: "Same as run", arg: "job", many: true, set: func(c *Args, arg string) { c.Jobs = append(c.Jobs, arg) }},
+	"list":       {usage: "List the jobs, their triggers and steps"},
+	"explain":    {usage: "Show which jobs a changed file triggers", arg: "file", set: func(c *Args, arg string) { c.Input = arg }},
+	"validate":   {usage: "Check a config file", arg: "file", flag: "config", set: func(c *Args, arg string) { c.ConfigFile = arg }},
+	"schema":     {usage: "Print the JSON Schema of vai.yml"},
+	"init":       {usage: "Detect the project and write vai.yml", arg: "file", flag: "config", set: func(c *Args, arg string) { c.ConfigFile = arg }},
+	"migrate":    {usage: "Convert an Air, Fresh, modd or reflex config", arg: "file", set: func(c *Args, arg string) { c.Input = arg }},
+	"completion": {usage: "Print a shell completion script", arg: "shell", set: func(c *Args, arg string) { c.Shell = arg }},
+	"__complete": {arg: "kind", set: func(c *Args, arg string) { c.Input = arg }},
 }
 
-// handleBoolFlag parses boolean flags
-func (c *Args) handleBoolFlag(flagName string) {
-	switch flagName {
-	case "help":
-		c.Help = true
-	case "debug":
-		c.Debug = true
-	case "version":
-		c.Version = true
-	case "save":
-		c.Save = true
+// boolFlag sets a boolean flag, a flag without value is true
+func boolFlag(b *bool, value string) error {
+	if value == "" {
+		*b = true
+		return nil
 	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid boolean '%s'", value)
+	}
+	*b = parsed
+	return nil
 }
 
-// parseArgs parses command line arguments
-func parseArgs(args []string) *Args {
+// parseArgs parses the command line, then the VAI_* variables of the flags not given
+func parseArgs(args []string) (*Args, error) {
 	c := &Args{
 		ConfigFile: "vai.yml",
 		SaveFile:   "vai.yml",
 	}
 
-	knownFlagsWithArg := map[string]bool{
-		"cmd": true, "path": true, "env": true, "regex": true,
-	}
-	knownBoolFlags := map[string]bool{
-		"help": true, "debug": true, "version": true, "save": true,
-	}
-	shortFlags := map[string]string{
-		"c": "cmd", "p": "path", "e": "env", "r": "regex", "s": "save",
-		"h": "help", "d": "debug", "v": "version",
-	}
-
-	i := 0
-	for i < len(args) {
+	used := make(map[string]bool)
+	hasArg := false
+	for i := 0; i < len(args); i++ {
 		arg := args[i]
-		isKnownFlag, flagName, attachedValue := identifyFlag(arg, knownFlagsWithArg, knownBoolFlags, shortFlags)
-
-		if isKnownFlag {
-			if flagName == "cmd" {
-				i = c.handleCmd(attachedValue, args, i, knownFlagsWithArg, knownBoolFlags, shortFlags)
-			} else if knownFlagsWithArg[flagName] {
-				i = c.handleArgFlag(flagName, attachedValue, args, i)
-			} else if knownBoolFlags[flagName] {
-				c.handleBoolFlag(flagName)
+
+		// Everything after -- is the command, or the argument of a subcommand
+		if arg == "--" {
+			if err := c.positional(args[i+1:], &hasArg); err != nil {
+				return nil, err
 			}
-		} else {
-			// The rest of the args belong to the cmd
-			c.PositionalArgs = args[i:]
 			break
 		}
-		i++
-	}
-	return c
-}
 
-// identifyFlag checks if an argument is a known flag
-func identifyFlag(arg string, knownFlagsWithArg, knownBoolFlags map[string]bool, shortFlags map[string]string) (bool, string, string) {
-	name := ""
-	value := ""
-	var found bool
-	if name, found = strings.CutPrefix(arg, "--"); found {
-	} else if name, found = strings.CutPrefix(arg, "-"); found {
-	} else {
-		return false, "", ""
-	}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			if c.Command == runMode && !hasArg {
+				if _, ok := subcommands[arg]; ok && !c.hasCmd() {
+					c.Command = arg
+					continue
+				}
+			}
+			// Flags after the command belong to it
+			if c.Command == runMode {
+				c.PositionalArgs = args[i:]
+				break
+			}
+			if err := c.positional([]string{arg}, &hasArg); err != nil {
+				return nil, err
+			}
+			continue
+		}
 
-	// Check for =
-	if before, after, found := strings.Cut(name, "="); found {
-		name = before
-		value = after
+		f, value, attached, err := lookupFlag(arg)
+		if err != nil {
+			return nil, err
+		}
+		used[f.name] = true
+
+		switch {
+		case f.name == "cmd" && !attached:
+			// --cmd takes the words up to the next flag
+			var words []string
+			for i+1 < len(args) && args[i+1] != "--" {
+				if strings.HasPrefix(args[i+1], "-") {
+					if _, _, _, err := lookupFlag(args[i+1]); err == nil {
+						break
+					}
+				}
+				i++
+				words = append(words, args[i])
+			}
+			if len(words) == 0 {
+				return nil, fmt.Errorf("flag --cmd needs a command")
+			}
+			value = strings.Join(words, " ")
+		case f.value && !attached:
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag --%s needs a value", f.name)
+			}
+			i++
+			value = args[i]
+		}
+		if err := f.set(c, value); err != nil {
+			return nil, fmt.Errorf("flag --%s: %v", f.name, err)
+		}
 	}
 
-	// Check long flags
-	if knownFlagsWithArg[name] || knownBoolFlags[name] {
-		return true, name, value
+	// Flags must belong to the command
+	for _, f := range cliFlags {
+		if used[f.name] && !f.accepts(c.Command) {
+			if c.Command == runMode {
+				return nil, fmt.Errorf("flag --%s only applies to vai %s", f.name, strings.Join(f.commands, ", "))
+			}
+			return nil, fmt.Errorf("flag --%s can't be used with vai %s", f.name, c.Command)
+		}
 	}
 
-	// Check short flags
-	if longName, ok := shortFlags[name]; ok {
-		return true, longName, value
+	// Environment variables fill the flags not given on the command line
+	if sub := subcommands[c.Command]; hasArg && sub.flag != "" {
+		used[sub.flag] = true
+	}
+	for _, f := range cliFlags {
+		name := envName(f.name)
+		value, ok := os.LookupEnv(name)
+		if !ok || used[f.name] || !f.accepts(c.Command) || f.name == "cmd" && c.hasCmd() {
+			continue
+		}
+		set := f.set
+		if f.env != nil {
+			set = f.env
+		}
+		if err := set(c, value); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
 	}
+	return c, nil
+}
 
-	return false, "", ""
+// positional handles arguments that aren't flags
+func (c *Args) positional(args []string, hasArg *bool) error {
+	if c.Command == runMode {
+		c.PositionalArgs = args
+		return nil
+	}
+	for _, arg := range args {
+		sub := subcommands[c.Command]
+		if sub.set == nil || *hasArg && !sub.many {
+			return fmt.Errorf("unexpected argument '%s' for vai %s", arg, c.Command)
+		}
+		sub.set(c, arg)
+		*hasArg = true
+	}
+	return nil
 }
 
-// parseCmdFlag extracts the command from arguments
-func parseCmdFlag(args []string, currentIndex int, knownFlagsWithArg, knownBoolFlags map[string]bool, shortFlags map[string]string) (string, int) {
-	var cmdParts []string
-	i := currentIndex + 1
-	for i < len(args) {
-		nextArg := args[i]
-		isNextArgAFlag, _, _ := identifyFlag(nextArg, knownFlagsWithArg, knownBoolFlags, shortFlags)
+// lookupFlag finds the flag of an argument like --name, --name=value, -n or -n=value
+func lookupFlag(arg string) (*cliFlag, string, bool, error) {
+	name, long := strings.CutPrefix(arg, "--")
+	if !long {
+		name = strings.TrimPrefix(arg, "-")
+	}
+	name, value, attached := strings.Cut(name, "=")
 
-		if isNextArgAFlag {
-			i--
-			break
+	for i := range cliFlags {
+		f := &cliFlags[i]
+		if long && f.name == name || !long && f.short != "" && f.short == name {
+			if attached && !f.value && !f.optional && value == "" {
+				return nil, "", false, fmt.Errorf("flag %s needs true or false after =", arg)
+			}
+			return f, value, attached, nil
 		}
-		cmdParts = append(cmdParts, nextArg)
-		i++
 	}
-	if len(cmdParts) > 0 {
-		return strings.Join(cmdParts, " "), i
+
+	names := make([]string, 0, len(cliFlags))
+	for _, f := range cliFlags {
+		names = append(names, f.name)
+	}
+	if s := closest(name, names); s != "" && long {
+		return nil, "", false, fmt.Errorf("unknown flag %s, did you mean --%s?", arg, s)
 	}
-	return "", i
+	return nil, "", false, fmt.Errorf("unknown flag %s, put -- before a command that starts with -", arg)
 }
 
-// parseValueFlag extracts the value for a flag
-func parseValueFlag(args []string, currentIndex int) (string, int) {
-	if currentIndex+1 < len(args) && !strings.HasPrefix(args[currentIndex+1], "-") {
-		return args[currentIndex+1], currentIndex + 1
-	}
-	return "", currentIndex
+// accepts reports whether a flag can be used with a command
+func (f *cliFlag) accepts(command string) bool {
+	return f.commands == nil || slices.Contains(f.commands, command)
+}
+
+// envName is the environment variable of a flag
+func envName(flag string) string {
+	return "VAI_" + strings.ToUpper(strings.ReplaceAll(flag, "-", "_"))
 }
 
 // printHelp prints usage help info
@@ -259,7 +436,7 @@ func printHelp() {
 		yellow("Usage:"),
 		"vai",
 		cyan("[flags]"),
-		cyan("[command...]..."),
+		cyan("[--] [command...]"),
 	)
 
 	fmt.Println()
@@ -281,6 +458,68 @@ func printHelp() {
 		"Use a vai.yml file for complex workflows (e.g., `vai`)",
 	)
 
+	// Commands
+	fmt.Println()
+	fmt.Println(yellow("Commands:"))
+
+	fmt.Println(
+		"  ",
+		cyan("run"),
+		"[job...]",
+		"Run jobs once without watching and exit with 1 if one fails, all jobs by default (alias: once)",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("list"),
+		"[--json]",
+		"List the jobs with their triggers, steps and state",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("explain"),
+		"[--json] <file>",
+		"Show which jobs a change of the file triggers and why the others don't",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("validate"),
+		"[file]",
+		"Check a config file and report errors with their line and column",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("schema"),
+		"Print the JSON Schema of vai.yml",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("init"),
+		"[-i, --interactive] [-f, --force] [file]",
+		"Detect the project and write a commented vai.yml",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("migrate"),
+		"[-f, --force] [file]",
+		"Convert an Air, Fresh, modd or reflex config into vai.yml",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("completion"),
+		"bash|zsh|fish|powershell",
+		"Print a shell completion script",
+	)
+
+	fmt.Println()
+	fmt.Println("A first word matching a command runs that command, use `vai -- run` to watch a program called run.")
+
 	// Flags
 	fmt.Println()
 	fmt.Println(yellow("Flags:"))
@@ -310,13 +549,63 @@ func printHelp() {
 		"  ",
 		cyan("-r, --regex"),
 		"<patterns>",
-		"Glob patterns to watch",
+		"Regex patterns to watch",
 	)
 
 	fmt.Println(
 		"  ",
-		cyan("-s, --save"),
-		"Save CLI flags to a new vai.yml file and exit",
+		cyan("-g, --glob"),
+		"<patterns>",
+		"Glob patterns to watch, relative to the path (e.g. **/*.go)",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("-s, --save[=file]"),
+		"Write the config file and CLI flags to vai.yml, or the given file, and exit",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("-f, --force"),
+		"Update an existing file with --save, keeping its comments",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("--config"),
+		"<file>",
+		"Config file to use (default: vai.yml)",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("--dry-run"),
+		"Print the resolved commands, their environment and directory instead of running them",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("--no-initial-run"),
+		"Wait for a change before running jobs, except the runOnStart: only ones",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("--json"),
+		"Print list and explain as JSON",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("-d, --debug"),
+		"Show debug logs",
+	)
+
+	fmt.Println(
+		"  ",
+		cyan("-v, --version"),
+		"Print the version and exit",
 	)
 
 	fmt.Println(
@@ -324,6 +613,10 @@ func printHelp() {
 		cyan("-h, --help"),
 		"Show this help message",
 	)
+
+	fmt.Println()
+	fmt.Println("Flags go before the command, use -- when the command itself starts with a flag.")
+	fmt.Println("Each flag can also be set with a VAI_ variable, e.g. VAI_PATH=./cmd/api or VAI_DEBUG=true.")
 }
 
 // printConfig prints the current config
@@ -334,6 +627,16 @@ func printConfig(v *Vai) {
 	fmt.Println(cyan("- Buffer Size:"), v.Config.BufferSize)
 	fmt.Println(cyan("- Severity:"), v.Config.Severity)
 	fmt.Println(cyan("- Clear CLI:"), v.Config.ClearCli)
+	fmt.Println(cyan("- Disable Hash Check:"), v.Config.DisableHashCheck)
+	fmt.Println(cyan("- Max Self Restarts:"), v.Config.MaxSelfRestarts)
+	fmt.Println(cyan("- Watcher:"), v.Config.Watcher)
+	if v.Config.Watcher != "native" {
+		fmt.Println(cyan("- Poll Interval:"), v.Config.PollInterval)
+		fmt.Println(cyan("- Poll Hash:"), v.Config.PollHash)
+	}
+	if v.Config.RespectGitignore != nil {
+		fmt.Println(cyan("- Respect Gitignore:"), *v.Config.RespectGitignore)
+	}
 
 	fmt.Println(yellow("---------------------"))
 
@@ -344,63 +647,103 @@ func printConfig(v *Vai) {
 	fmt.Println()
 	fmt.Println(yellow("--- Jobs ---"))
 
-	for name, job := range v.Jobs {
-		fmt.Println(cyan("- Job:"), name)
+	for _, name := range slices.Sorted(maps.Keys(v.Jobs)) {
+		printJob(name, v.Jobs[name])
+	}
 
-		if job.Trigger != nil {
-			if len(job.Trigger.Paths) > 0 {
-				fmt.Println(
-					"  ",
-					cyan("- Watch Paths:"),
-					strings.Join(job.Trigger.Paths, ", "),
-				)
-			}
+	fmt.Println(yellow("------------"))
+}
 
-			if len(job.Trigger.Regex) > 0 {
-				fmt.Println(
-					"  ",
-					cyan("- Inclusion Regex:"),
-					strings.Join(job.Trigger.Regex, ", "),
-				)
-			}
+// printJob prints the trigger, steps and environment of a job
+func printJob(name string, job Job) {
+	fmt.Println(cyan("- Job:"), name)
+
+	if job.Trigger != nil {
+		if len(job.Trigger.Paths) > 0 {
+			fmt.Println(
+				"  ",
+				cyan("- Watch Paths:"),
+				strings.Join(job.Trigger.Paths, ", "),
+			)
 		}
 
-		if len(job.Series) > 0 {
-			fmt.Println("  ", cyan("- Commands:"))
-
-			var printSubJobs func([]Job, string)
-			printSubJobs = func(jobs []Job, indent string) {
-				for _, j := range jobs {
-					if j.Cmd != "" {
-						cmd := j.Cmd
-						if len(j.Params) > 0 {
-							cmd += " " + strings.Join(j.Params, " ")
-						}
-						fmt.Println(indent, white("- ", cmd))
-					} else if len(j.Parallel) > 0 {
-						fmt.Println(indent, white("- Parallel:"))
-						printSubJobs(j.Parallel, indent+"  ")
-					} else if len(j.Series) > 0 {
-						fmt.Println(indent, white("- Series:"))
-						printSubJobs(j.Series, indent+"  ")
-					}
-				}
-			}
-			printSubJobs(job.Series, "    ")
+		if len(job.Trigger.Regex) > 0 {
+			fmt.Println(
+				"  ",
+				cyan("- Inclusion Regex:"),
+				strings.Join(job.Trigger.Regex, ", "),
+			)
+		}
+
+		if len(job.Trigger.Glob) > 0 {
+			fmt.Println(
+				"  ",
+				cyan("- Glob:"),
+				strings.Join(job.Trigger.Glob, ", "),
+			)
 		}
 
-		if len(job.Env) > 0 {
-			fmt.Println("  ", cyan("- Environment:"))
+		if len(job.Trigger.Events) > 0 {
+			fmt.Println(
+				"  ",
+				cyan("- Events:"),
+				strings.Join(job.Trigger.Events, ", "),
+			)
+		}
+	}
+
+	if job.Cmd != "" || len(job.Series)+len(job.Parallel)+len(job.Before)+len(job.After) > 0 {
+		fmt.Println("  ", cyan("- Commands:"))
+		printSteps(job, "    ")
+	}
+
+	if len(job.Env) > 0 {
+		fmt.Println("  ", cyan("- Environment:"))
 
-			for key, val := range job.Env {
-				fmt.Println(
-					"    ",
-					white("- ", key+":"),
-					val,
-				)
+		for _, key := range slices.Sorted(maps.Keys(job.Env)) {
+			fmt.Println(
+				"    ",
+				white("- ", key+":"),
+				job.Env[key],
+			)
+		}
+	}
+}
+
+// printSteps prints the steps of a job as a tree, a series is flattened into its parent
+func printSteps(j Job, indent string) {
+	for _, b := range j.Before {
+		fmt.Println(indent, white("- Before:"))
+		printSteps(b, indent+"  ")
+	}
+	switch {
+	case j.Cmd != "":
+		fmt.Println(indent, white("- ", commandString(j)))
+	case len(j.Parallel) > 0:
+		fmt.Println(indent, white("- Parallel:"))
+		for _, p := range j.Parallel {
+			printSteps(p, indent+"  ")
+		}
+	case len(j.Series) > 0:
+		for _, step := range j.Series {
+			if len(step.Series) > 0 {
+				fmt.Println(indent, white("- Series:"))
+				printSteps(step, indent+"  ")
+				continue
 			}
+			printSteps(step, indent)
 		}
 	}
+	for _, a := range j.After {
+		fmt.Println(indent, white("- After:"))
+		printSteps(a, indent+"  ")
+	}
+}
 
-	fmt.Println(yellow("------------"))
+// commandString returns the command line of a step
+func commandString(j Job) string {
+	if len(j.Params) == 0 {
+		return j.Cmd
+	}
+	return j.Cmd + " " + strings.Join(j.Params, " ")
 }
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -58,7 +59,8 @@ func TestNewVai(t *testing.T) {
 		content := `
 jobs:
   from-file:
-    cmd: echo hello
+    cmd: echo
+    params: [hello]
     trigger:
       paths: ["/file"]
 `
@@ -88,6 +90,32 @@ jobs:
 		}
 	})
 
+	t.Run("Invalid trigger pattern fails startup", func(t *testing.T) {
+		content := `
+jobs:
+  broken:
+    cmd: echo
+    params: [hello]
+    trigger:
+      regex: ["(unclosed"]
+`
+		tmpfile, err := os.CreateTemp("", "vai.*.yml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.Remove(tmpfile.Name())
+		tmpfile.Write([]byte(content))
+		tmpfile.Close()
+
+		_, err = newVai(&Args{ConfigFile: tmpfile.Name()})
+		if err == nil {
+			t.Fatal("Expected newVai to fail for an invalid regex")
+		}
+		if !strings.Contains(err.Error(), "broken") || !strings.Contains(err.Error(), "(unclosed") {
+			t.Errorf("Expected error to name the job and pattern, got %v", err)
+		}
+	})
+
 	t.Run("Debug severity is set correctly via config", func(t *testing.T) {
 		content := `
 config:
@@ -118,7 +146,7 @@ config:
 func TestParseArgs(t *testing.T) {
 	t.Run("parses boolean flags", func(t *testing.T) {
 		args := []string{"--debug", "--help", "--version", "--save"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		if !cli.Debug {
 			t.Error("Expected Debug to be true")
@@ -136,7 +164,7 @@ func TestParseArgs(t *testing.T) {
 
 	t.Run("parses value flags", func(t *testing.T) {
 		args := []string{"--path", "./foo", "--env", "K=V", "--regex", ".*"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		if cli.Path != "./foo" {
 			t.Errorf("Expected Path to be './foo', got '%s'", cli.Path)
@@ -151,7 +179,7 @@ func TestParseArgs(t *testing.T) {
 
 	t.Run("parses short flags", func(t *testing.T) {
 		args := []string{"-p", "./bar", "-e", "A=B", "-r", "^main", "-d", "-s"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		if cli.Path != "./bar" {
 			t.Errorf("Expected Path to be './bar', got '%s'", cli.Path)
@@ -170,9 +198,31 @@ func TestParseArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("parses the save file", func(t *testing.T) {
+		cli := mustParseArgs(t, []string{"-f", "--save=dev.yml", "go", "run", "."})
+		if !cli.Save || !cli.Force || cli.SaveFile != "dev.yml" {
+			t.Errorf("Expected save to dev.yml with force, got %+v", cli)
+		}
+		if len(cli.PositionalArgs) != 3 {
+			t.Errorf("Expected the command to be kept, got %v", cli.PositionalArgs)
+		}
+	})
+
+	t.Run("takes any save value as a file", func(t *testing.T) {
+		for _, name := range []string{"1", "t", "false"} {
+			cli := mustParseArgs(t, []string{"--save=" + name, "go", "run", "."})
+			if !cli.Save || cli.SaveFile != name {
+				t.Errorf("Expected save to %s, got %+v", name, cli)
+			}
+		}
+		if cli := mustParseArgs(t, []string{"--save", "go", "run", "."}); !cli.Save || cli.SaveFile != "vai.yml" {
+			t.Errorf("Expected a bare --save to use vai.yml, got %+v", cli)
+		}
+	})
+
 	t.Run("parses command flags", func(t *testing.T) {
 		args := []string{"--cmd", "echo hello", "-c", "ls -la"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		expectedCmds := []string{"echo hello", "ls -la"}
 		if !reflect.DeepEqual(cli.CmdFlags, expectedCmds) {
@@ -182,7 +232,7 @@ func TestParseArgs(t *testing.T) {
 
 	t.Run("parses positional arguments", func(t *testing.T) {
 		args := []string{"-p", ".", "echo", "arg1", "arg2"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		if cli.Path != "." {
 			t.Errorf("Expected Path to be '.', got '%s'", cli.Path)
@@ -196,7 +246,7 @@ func TestParseArgs(t *testing.T) {
 	t.Run("parses command flag consuming subsequent args", func(t *testing.T) {
 		// This tests the parseCmdFlag logic where it consumes args until next flag
 		args := []string{"--cmd", "go", "run", ".", "--debug"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		expectedCmds := []string{"go run ."}
 		if !reflect.DeepEqual(cli.CmdFlags, expectedCmds) {
@@ -207,9 +257,29 @@ func TestParseArgs(t *testing.T) {
 		}
 	})
 
+	t.Run("parses glob flag", func(t *testing.T) {
+		args := []string{"-g", "**/*.go,!**/*_test.go", "go", "test"}
+		cli := mustParseArgs(t, args)
+
+		if cli.Glob != "**/*.go,!**/*_test.go" {
+			t.Errorf("Expected Glob to be '**/*.go,!**/*_test.go', got '%s'", cli.Glob)
+		}
+	})
+
+	t.Run("parses validate subcommand", func(t *testing.T) {
+		cli := mustParseArgs(t, []string{"validate", "vai.dev.yml"})
+
+		if cli.Command != "validate" || cli.ConfigFile != "vai.dev.yml" {
+			t.Errorf("Expected validate on vai.dev.yml, got %q on %q", cli.Command, cli.ConfigFile)
+		}
+		if len(cli.PositionalArgs) != 0 {
+			t.Errorf("Expected no command to run, got %v", cli.PositionalArgs)
+		}
+	})
+
 	t.Run("parses flags with attached values", func(t *testing.T) {
 		args := []string{"--path=./baz", "--env=X=Y"}
-		cli := parseArgs(args)
+		cli := mustParseArgs(t, args)
 
 		if cli.Path != "./baz" {
 			t.Errorf("Expected Path to be './baz', got '%s'", cli.Path)
@@ -219,3 +289,159 @@ func TestParseArgs(t *testing.T) {
 		}
 	})
 }
+
+func TestParseArgs_Separator(t *testing.T) {
+	cli := mustParseArgs(t, []string{"--regex", "x", "--", "go", "run", ".", "-p", "8080"})
+	if cli.Regex != "x" || cli.Path != "" {
+		t.Errorf("Expected only the flags before -- to be vai's, got %+v", cli)
+	}
+	if expected := []string{"go", "run", ".", "-p", "8080"}; !reflect.DeepEqual(cli.PositionalArgs, expected) {
+		t.Errorf("Expected PositionalArgs to be %v, got %v", expected, cli.PositionalArgs)
+	}
+
+	// Flags after the command belong to it
+	cli = mustParseArgs(t, []string{"go", "test", "-v", "-run", "Foo"})
+	if cli.Version || len(cli.PositionalArgs) != 5 {
+		t.Errorf("Expected the flags to be kept in the command, got %+v", cli)
+	}
+
+	// -- keeps a command named like a subcommand
+	cli = mustParseArgs(t, []string{"--", "init"})
+	if cli.Command != "" || !reflect.DeepEqual(cli.PositionalArgs, []string{"init"}) {
+		t.Errorf("Expected init to be the command to run, got %+v", cli)
+	}
+}
+
+func TestParseArgs_CommandNamedRun(t *testing.T) {
+	resetGlobals()
+	t.Chdir(t.TempDir())
+
+	// The first word is the run subcommand
+	if cli := mustParseArgs(t, []string{"run", "build"}); cli.Command != "run" || !reflect.DeepEqual(cli.Jobs, []string{"build"}) {
+		t.Errorf("Expected the run subcommand, got %+v", cli)
+	}
+
+	// After -- it's the program to watch
+	cli := mustParseArgs(t, []string{"--", "run", "--port", "8080"})
+	if cli.Command != runMode {
+		t.Fatalf("Expected to watch a program, got the %s subcommand", cli.Command)
+	}
+	v, err := newVai(cli)
+	if err != nil {
+		t.Fatalf("newVai failed: %v", err)
+	}
+	job := v.Jobs["default"]
+	if len(job.Series) != 1 || job.Series[0].Cmd != "run" || !reflect.DeepEqual(job.Series[0].Params, []string{"--port", "8080"}) {
+		t.Errorf("Expected a job running the program run, got %+v", job.Series)
+	}
+}
+
+func TestParseArgs_Subcommands(t *testing.T) {
+	cli := mustParseArgs(t, []string{"--debug", "init", "-i", "--force", "dev.yml"})
+	if cli.Command != "init" || !cli.Debug || !cli.Interactive || !cli.Force || cli.ConfigFile != "dev.yml" {
+		t.Errorf("Expected init with its flags, got %+v", cli)
+	}
+	cli = mustParseArgs(t, []string{"migrate", "modd.conf"})
+	if cli.Input != "modd.conf" || cli.ConfigFile != "vai.yml" {
+		t.Errorf("Expected migrate to read modd.conf, got %+v", cli)
+	}
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--pth", "."}, "unknown flag --pth, did you mean --path?"},
+		{[]string{"-x", "go", "run", "."}, "unknown flag -x"},
+		{[]string{"--path"}, "flag --path needs a value"},
+		{[]string{"--debug=maybe"}, "flag --debug: invalid boolean 'maybe'"},
+		{[]string{"-i", "go", "run", "."}, "flag --interactive only applies to vai init"},
+		{[]string{"validate", "--path", "."}, "flag --path can't be used with vai validate"},
+		{[]string{"validate", "a.yml", "b.yml"}, "unexpected argument 'b.yml' for vai validate"},
+		{[]string{"schema", "x"}, "unexpected argument 'x' for vai schema"},
+	}
+	for _, tt := range tests {
+		_, err := parseArgs(tt.args)
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("parseArgs(%q): expected error %q, got %v", tt.args, tt.expected, err)
+		}
+	}
+}
+
+func TestParseArgs_Env(t *testing.T) {
+	t.Setenv("VAI_PATH", "./env")
+	t.Setenv("VAI_DEBUG", "true")
+	t.Setenv("VAI_CMD", "go test ./...")
+	t.Setenv("VAI_INTERACTIVE", "true")
+	t.Setenv("VAI_DRY_RUN", "true")
+
+	cli := mustParseArgs(t, []string{"--path", "./flag"})
+	if cli.Path != "./flag" {
+		t.Errorf("Expected the flag to win over VAI_PATH, got %s", cli.Path)
+	}
+	if !cli.Debug || !reflect.DeepEqual(cli.CmdFlags, []string{"go test ./..."}) {
+		t.Errorf("Expected VAI_DEBUG and VAI_CMD to apply, got %+v", cli)
+	}
+	if cli.Interactive {
+		t.Error("Expected VAI_INTERACTIVE to be ignored outside of vai init")
+	}
+	if !cli.DryRun {
+		t.Error("Expected VAI_DRY_RUN to enable --dry-run")
+	}
+
+	cli = mustParseArgs(t, []string{"go", "run", "."})
+	if len(cli.CmdFlags) != 0 {
+		t.Errorf("Expected VAI_CMD to be ignored with a command, got %v", cli.CmdFlags)
+	}
+
+	t.Setenv("VAI_DEBUG", "sure")
+	if _, err := parseArgs(nil); err == nil || !strings.HasPrefix(err.Error(), "VAI_DEBUG") {
+		t.Errorf("Expected an error naming VAI_DEBUG, got %v", err)
+	}
+}
+
+func TestParseArgs_EnvConfig(t *testing.T) {
+	t.Setenv("VAI_CONFIG", "missing.yml")
+
+	for _, command := range []string{"validate", "init"} {
+		cli := mustParseArgs(t, []string{command, "other.yml"})
+		if cli.ConfigFile != "other.yml" {
+			t.Errorf("Expected the file of vai %s to win over VAI_CONFIG, got %s", command, cli.ConfigFile)
+		}
+		cli = mustParseArgs(t, []string{command})
+		if cli.ConfigFile != "missing.yml" {
+			t.Errorf("Expected VAI_CONFIG to apply to vai %s without a file, got %s", command, cli.ConfigFile)
+		}
+	}
+}
+
+func TestParseArgs_EnvSave(t *testing.T) {
+	testCases := []struct {
+		value string
+		save  bool
+		file  string
+	}{
+		{"true", true, "vai.yml"},
+		{"1", true, "vai.yml"},
+		{"false", false, "vai.yml"},
+		{"vai.dev.yml", true, "vai.dev.yml"},
+	}
+	for _, tc := range testCases {
+		t.Setenv("VAI_SAVE", tc.value)
+		cli := mustParseArgs(t, []string{"echo", "hi"})
+		if cli.Save != tc.save || cli.SaveFile != tc.file {
+			t.Errorf("VAI_SAVE=%s: expected save %v to %s, got %v to %s", tc.value, tc.save, tc.file, cli.Save, cli.SaveFile)
+		}
+	}
+}
+
+// mustParseArgs parses a command line that is expected to be valid
+func mustParseArgs(t *testing.T, args []string) *Args {
+	t.Helper()
+	cli, err := parseArgs(args)
+	if err != nil {
+		t.Fatalf("parseArgs(%q) failed: %v", args, err)
+	}
+	return cli
+}
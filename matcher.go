@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// pattern is a compiled regex or glob trigger pattern
+type pattern struct {
+	src  string
+	re   *regexp.Regexp
+	glob bool
+}
+
+// matcher evaluates the inclusion and exclusion patterns of a trigger
+type matcher struct {
+	include []pattern
+	exclude []pattern
+}
+
+// newMatcher compiles regex and glob patterns, a leading ! marks an exclusion.
+// Both are evaluated against the slash-separated path relative to the trigger root
+func newMatcher(regex, globs []string) (*matcher, error) {
+	m := &matcher{}
+	for _, rx := range regex {
+		src, exclude := strings.CutPrefix(rx, "!")
+		re, err := regexp.Compile(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex '%s': %v", rx, err)
+		}
+		m.add(pattern{src: rx, re: re}, exclude)
+	}
+	for _, g := range globs {
+		src, exclude := strings.CutPrefix(g, "!")
+		expr, err := globToRegex(src)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", g, err)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob '%s': %v", g, err)
+		}
+		m.add(pattern{src: g, re: re, glob: true}, exclude)
+	}
+	return m, nil
+}
+
+// add appends a compiled pattern to the matcher
+func (m *matcher) add(p pattern, exclude bool) {
+	if exclude {
+		m.exclude = append(m.exclude, p)
+	} else {
+		m.include = append(m.include, p)
+	}
+}
+
+// match checks a slash-separated path relative to the trigger root
+func (m *matcher) match(relPath string) bool {
+	_, ok := m.decide(relPath)
+	return ok
+}
+
+// decide matches a path and returns the pattern deciding it, nil when no pattern did
+func (m *matcher) decide(relPath string) (*pattern, bool) {
+	for i, p := range m.exclude {
+		if p.re.MatchString(relPath) {
+			return &m.exclude[i], false
+		}
+	}
+
+	// If no inclusion patterns are defined, we default to including
+	if len(m.include) == 0 {
+		return nil, true
+	}
+	for i, p := range m.include {
+		if p.re.MatchString(relPath) {
+			return &m.include[i], true
+		}
+	}
+	return nil, false
+}
+
+// root is a trigger path in absolute and symlink-resolved form
+type root struct {
+	abs       string
+	canonical string
+}
+
+// compile resolves the trigger roots and compiles its patterns
+func (t *Trigger) compile() error {
+	m, err := newMatcher(t.Regex, t.Glob)
+	if err != nil {
+		return err
+	}
+	t.matcher = m
+
+	t.roots = t.roots[:0]
+	for _, p := range t.Paths {
+		abs, canonical := resolvePath(p)
+		t.roots = append(t.roots, root{abs: abs, canonical: canonical})
+	}
+	return nil
+}
+
+// relPath returns the event path relative to the first trigger root containing it
+func (t *Trigger) relPath(abs, canonical string) (string, bool) {
+	for _, r := range t.roots {
+		rel, ok := within(r.abs, abs)
+		if !ok {
+			rel, ok = within(r.canonical, canonical)
+		}
+		if !ok {
+			continue
+		}
+		// The trigger path is the file itself
+		if rel == "." {
+			rel = filepath.Base(abs)
+		}
+		return rel, true
+	}
+	return "", false
+}
+
+// within returns target relative to dir, slash-separated, if target is dir or inside it
+func within(dir, target string) (string, bool) {
+	rel, err := filepath.Rel(dir, target)
+	if err != nil || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// resolvePath returns the absolute and symlink-resolved forms of a path
+func resolvePath(path string) (string, string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	if canonical, err := filepath.EvalSymlinks(abs); err == nil {
+		return abs, canonical
+	}
+	// Removed files can't be resolved, resolve their directory instead
+	if dir, err := filepath.EvalSymlinks(filepath.Dir(abs)); err == nil {
+		return abs, filepath.Join(dir, filepath.Base(abs))
+	}
+	return abs, abs
+}
+
+// globToRegex converts a doublestar glob into an anchored regex
+func globToRegex(glob string) (string, error) {
+	var b strings.Builder
+	b.WriteString("^")
+
+	// Runes keep multibyte characters whole
+	runes := []rune(glob)
+	depth := 0
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				atStart := i == 0 || runes[i-1] == '/'
+				if atStart && i+2 < len(runes) && runes[i+2] == '/' {
+					// **/ matches zero or more directories
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+				continue
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := slices.Index(runes[i+1:], ']')
+			if end < 0 {
+				return "", fmt.Errorf("unclosed '['")
+			}
+			class := string(runes[i+1 : i+1+end])
+			if negated, ok := strings.CutPrefix(class, "!"); ok {
+				class = "^" + negated
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			depth++
+			b.WriteString("(?:")
+		case '}':
+			if depth == 0 {
+				return "", fmt.Errorf("unexpected '}'")
+			}
+			depth--
+			b.WriteString(")")
+		case ',':
+			if depth > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if depth > 0 {
+		return "", fmt.Errorf("unclosed '{'")
+	}
+
+	b.WriteString("$")
+	return b.String(), nil
+}
+
+// splitPatterns splits a comma-separated flag value, keeping commas inside braces
+func splitPatterns(flag string) []string {
+	var patterns []string
+	depth := 0
+	start := 0
+	for i, c := range flag {
+		switch c {
+		case '{':
+			depth++
+		case '}':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				patterns = append(patterns, strings.TrimSpace(flag[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	patterns = append(patterns, strings.TrimSpace(flag[start:]))
+	return patterns
+}
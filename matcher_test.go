@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestMatcher_Glob(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		globs    []string
+		expected bool
+	}{
+		{"Star matches in root", "main.go", []string{"*.go"}, true},
+		{"Star does not cross directories", "cmd/main.go", []string{"*.go"}, false},
+		{"Doublestar matches root", "main.go", []string{"**/*.go"}, true},
+		{"Doublestar matches nested", "internal/api/server.go", []string{"**/*.go"}, true},
+		{"Exclusion wins", "internal/api/server_test.go", []string{"**/*.go", "!**/*_test.go"}, false},
+		{"Braces", "web/static/site.css", []string{"web/**/*.{html,css}"}, true},
+		{"Braces no match", "web/static/site.js", []string{"web/**/*.{html,css}"}, false},
+		{"Trailing doublestar", "vendor/a/b.go", []string{"*.go", "!vendor/**"}, false},
+		{"Question mark", "a1.txt", []string{"a?.txt"}, true},
+		{"Character class", "b.go", []string{"[!a].go"}, true},
+		{"Dots are literal", "mainxgo", []string{"main.go"}, false},
+		{"Non-ASCII directory", "café/a.go", []string{"café/*.go"}, true},
+		{"Non-ASCII escaped", "naïve?.txt", []string{"naïve\\?.txt"}, true},
+		{"Non-ASCII character class", "ü.go", []string{"[äöü].go"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := newMatcher(nil, tc.globs)
+			if err != nil {
+				t.Fatalf("newMatcher failed: %v", err)
+			}
+			if result := m.match(tc.path); result != tc.expected {
+				t.Errorf("match(%q, %v) = %v, want %v", tc.path, tc.globs, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestNewMatcher_Errors(t *testing.T) {
+	if _, err := newMatcher([]string{`(`}, nil); err == nil {
+		t.Error("Expected an error for an invalid regex")
+	}
+	if _, err := newMatcher(nil, []string{"web/{a,b"}); err == nil {
+		t.Error("Expected an error for an unclosed brace")
+	}
+	if _, err := newMatcher(nil, []string{"[ab"}); err == nil {
+		t.Error("Expected an error for an unclosed class")
+	}
+}
+
+func TestSplitPatterns(t *testing.T) {
+	patterns := splitPatterns("**/*.go, !**/*_test.go,web/**/*.{html,css}")
+	expected := []string{"**/*.go", "!**/*_test.go", "web/**/*.{html,css}"}
+	if !reflect.DeepEqual(patterns, expected) {
+		t.Errorf("Expected %v, got %v", expected, patterns)
+	}
+}
+
+func TestTrigger_Match(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping symlink tests on Windows")
+	}
+
+	// Resolve the temp dir so the cases below control symlinks explicitly
+	base, _ := filepath.EvalSymlinks(t.TempDir())
+	os.MkdirAll(filepath.Join(base, "api"), 0755)
+	os.MkdirAll(filepath.Join(base, "api-gateway"), 0755)
+	os.MkdirAll(filepath.Join(base, "real", "sub"), 0755)
+	os.Symlink(filepath.Join(base, "real"), filepath.Join(base, "link"))
+	os.WriteFile(filepath.Join(base, "go.mod"), []byte("module x"), 0644)
+	os.WriteFile(filepath.Join(base, "real", "sub", "main.go"), []byte("package main"), 0644)
+
+	testCases := []struct {
+		name     string
+		paths    []string
+		regex    []string
+		glob     []string
+		event    string
+		rel      string
+		expected bool
+	}{
+		{"File inside root", []string{"api"}, nil, nil, "api/x.go", "x.go", true},
+		{"Sibling with shared prefix", []string{"api"}, nil, nil, "api-gateway/x.go", "", false},
+		{"Parent of root", []string{"api"}, nil, nil, "go.mod", "", false},
+		{"Anchored regex on root file", []string{"."}, []string{`^go\.mod$`}, nil, "go.mod", "go.mod", true},
+		{"Anchored regex on nested file", []string{"."}, []string{`^go\.mod$`}, nil, "api/go.mod", "api/go.mod", false},
+		{"Relative directory regex", []string{"."}, []string{`^api/.*\.go$`}, nil, "api/x.go", "api/x.go", true},
+		{"Exclusion relative to root", []string{"."}, []string{`\.go$`, `!^api/`}, nil, "api/x.go", "api/x.go", false},
+		{"Glob relative to root", []string{"api"}, nil, []string{"*.go"}, "api/x.go", "x.go", true},
+		{"File as trigger path", []string{"go.mod"}, []string{`^go\.mod$`}, nil, "go.mod", "go.mod", true},
+		{"Symlinked root, event on real path", []string{"link"}, nil, []string{"sub/*.go"}, "real/sub/main.go", "sub/main.go", true},
+		{"Real root, event on symlinked path", []string{"real"}, nil, []string{"sub/*.go"}, "link/sub/main.go", "sub/main.go", true},
+		{"Removed file under symlinked root", []string{"link"}, nil, []string{"sub/*.go"}, "real/sub/gone.go", "sub/gone.go", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			trigger := &Trigger{Regex: tc.regex, Glob: tc.glob}
+			for _, p := range tc.paths {
+				trigger.Paths = append(trigger.Paths, filepath.Join(base, p))
+			}
+			if err := trigger.compile(); err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+
+			abs, canonical := resolvePath(filepath.Join(base, tc.event))
+			rel, ok := trigger.relPath(abs, canonical)
+			if ok && rel != tc.rel {
+				t.Errorf("Expected relative path %q, got %q", tc.rel, rel)
+			}
+			if result := ok && trigger.matcher.match(rel); result != tc.expected {
+				t.Errorf("Event %q on paths %v = %v, want %v", tc.event, tc.paths, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMatcher_Decide(t *testing.T) {
+	m, err := newMatcher(nil, []string{"**/*.go", "!**/*_test.go"})
+	if err != nil {
+		t.Fatalf("newMatcher failed: %v", err)
+	}
+	if p, ok := m.decide("main.go"); !ok || p == nil || p.src != "**/*.go" {
+		t.Errorf("Expected main.go to be included by **/*.go, got %v %v", p, ok)
+	}
+	if p, ok := m.decide("main_test.go"); ok || p == nil || p.src != "!**/*_test.go" {
+		t.Errorf("Expected main_test.go to be excluded by !**/*_test.go, got %v %v", p, ok)
+	}
+	if p, ok := m.decide("README.md"); ok || p != nil {
+		t.Errorf("Expected README.md to match no pattern, got %v %v", p, ok)
+	}
+}
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// migrateSources are the config files of other tools, in lookup order
+var migrateSources = []string{".air.toml", "air.toml", "runner.conf", "modd.conf", "reflex.conf"}
+
+// migration is a config converted from another tool and the settings left behind
+type migration struct {
+	vai   *Vai
+	notes []string
+}
+
+// note records a setting that couldn't be translated
+func (m *migration) note(format string, args ...any) {
+	m.notes = append(m.notes, fmt.Sprintf(format, args...))
+}
+
+// runMigrate converts the config of another tool into a vai config file
+func runMigrate(args *Args) int {
+	source := args.Input
+	if source == "" {
+		for _, s := range migrateSources {
+			if fileExists(s) {
+				source = s
+				break
+			}
+		}
+		if source == "" {
+			logger.log(SeverityError, OpError, "No config to migrate found, looked for %s", strings.Join(migrateSources, ", "))
+			return 1
+		}
+	}
+	if fileExists(args.ConfigFile) && !args.Force {
+		logger.log(SeverityError, OpError, "%s already exists, use --force to overwrite it", args.ConfigFile)
+		return 1
+	}
+
+	m, err := migrate(source)
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to migrate %s: %v", source, err)
+		return 1
+	}
+
+	comments := []string{fmt.Sprintf("Migrated from %s by vai migrate", filepath.Base(source))}
+	if len(m.notes) > 0 {
+		comments = append(comments, "Not translated:")
+		for _, n := range m.notes {
+			comments = append(comments, "  - "+n)
+		}
+	}
+	if err := m.vai.save(args.ConfigFile, comments...); err != nil {
+		logger.log(SeverityError, OpError, "Failed to write %s: %v", args.ConfigFile, err)
+		return 1
+	}
+
+	fmt.Printf("%s %s from %s\n", green("Created"), args.ConfigFile, source)
+	for _, n := range m.notes {
+		logger.log(SeverityWarn, OpWarn, "Not translated: %s", n)
+	}
+	return 0
+}
+
+// migrate reads the config of another tool, the format is detected from the file name
+func migrate(source string) (*migration, error) {
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	content := string(data)
+
+	switch name := filepath.Base(source); {
+	case strings.HasSuffix(name, ".toml"):
+		return migrateAir(content)
+	case name == "runner.conf":
+		return migrateFresh(content), nil
+	case strings.HasPrefix(name, "modd"):
+		return migrateModd(content)
+	case strings.HasPrefix(name, "reflex"):
+		return migrateReflex(content)
+	}
+	return nil, fmt.Errorf("unknown format, expected one of %s", strings.Join(migrateSources, ", "))
+}
+
+// migrateAir converts an Air .air.toml
+func migrateAir(content string) (*migration, error) {
+	tables, err := parseTOML(content)
+	if err != nil {
+		return nil, err
+	}
+	m := &migration{vai: &Vai{Jobs: map[string]Job{}}}
+	top, build, screen := tables[""], tables["build"], tables["screen"]
+
+	root := tomlString(top, "root", ".")
+	bin := tomlString(build, "bin", "./tmp/main")
+	includeExt := tomlStrings(build, "include_ext", []string{"go", "tpl", "tmpl", "html"})
+	excludeDir := tomlStrings(build, "exclude_dir", []string{"assets", "tmp", "vendor", "testdata"})
+
+	job := Job{Trigger: &Trigger{Paths: []string{root}}}
+
+	// Build then run the binary, full_bin may set environment variables
+	if cmd := tomlString(build, "cmd", "go build -o ./tmp/main ."); cmd != "" {
+		job.Series = append(job.Series, commandStep(cmd))
+	}
+	run := bin
+	if full := tomlString(build, "full_bin", ""); full != "" {
+		var env map[string]string
+		env, run = splitEnv(full)
+		job.Env = env
+	}
+	step := commandStep(run)
+	// Air resolves bin from the root, make it explicit for the shell-less run
+	if strings.Contains(step.Cmd, "/") && !strings.HasPrefix(step.Cmd, ".") && !path.IsAbs(step.Cmd) {
+		step.Cmd = "./" + step.Cmd
+	}
+	step.Params = append(step.Params, tomlStrings(build, "args_bin", nil)...)
+	job.Series = append(job.Series, step)
+	for _, pre := range tomlStrings(build, "pre_cmd", nil) {
+		job.Before = append(job.Before, commandStep(pre))
+	}
+
+	// Watched files
+	exts := "*." + strings.Join(includeExt, ",*.")
+	if len(includeExt) > 1 {
+		exts = "*.{" + strings.Join(includeExt, ",") + "}"
+	}
+	includeDir := tomlStrings(build, "include_dir", nil)
+	if len(includeDir) == 0 {
+		job.Trigger.Glob = append(job.Trigger.Glob, "**/"+exts)
+	}
+	for _, dir := range includeDir {
+		job.Trigger.Glob = append(job.Trigger.Glob, path.Join(dir, "**", exts))
+	}
+	job.Trigger.Glob = append(job.Trigger.Glob, tomlStrings(build, "include_file", nil)...)
+	for _, dir := range excludeDir {
+		job.Trigger.Glob = append(job.Trigger.Glob, "!"+path.Join(dir, "**"))
+	}
+	for _, file := range tomlStrings(build, "exclude_file", nil) {
+		job.Trigger.Glob = append(job.Trigger.Glob, "!"+file)
+	}
+	for _, rx := range tomlStrings(build, "exclude_regex", nil) {
+		job.Trigger.Regex = append(job.Trigger.Regex, "!"+rx)
+	}
+	if delay := tomlInt(build, "delay", 0); delay > 0 {
+		job.Trigger.Batch = time.Duration(delay) * time.Millisecond
+	}
+	m.vai.Jobs["app"] = job
+
+	// Watcher options
+	if b, ok := build["poll"].(bool); ok && b {
+		m.vai.Config.Watcher = "poll"
+	}
+	if interval := tomlInt(build, "poll_interval", 0); interval > 0 {
+		m.vai.Config.PollInterval = time.Duration(interval) * time.Millisecond
+	}
+	if b, ok := screen["clear_on_rebuild"].(bool); ok {
+		m.vai.Config.ClearCli = b
+	}
+	// Air rebuilds on saves without changes unless told otherwise, vai skips them by default
+	if b, ok := build["exclude_unchanged"].(bool); ok {
+		m.vai.Config.DisableHashCheck = !b
+	}
+
+	// Everything else is listed for the user
+	translated := map[string][]string{
+		"":       {"root", "tmp_dir", "testdata_dir"},
+		"build":  {"cmd", "bin", "full_bin", "args_bin", "pre_cmd", "include_ext", "include_dir", "include_file", "exclude_dir", "exclude_file", "exclude_regex", "exclude_unchanged", "delay", "poll", "poll_interval"},
+		"screen": {"clear_on_rebuild"},
+	}
+	for _, table := range slices.Sorted(maps.Keys(tables)) {
+		for _, key := range slices.Sorted(maps.Keys(tables[table])) {
+			if slices.Contains(translated[table], key) {
+				continue
+			}
+			if table == "" {
+				m.note("%s = %v", key, tables[table][key])
+			} else {
+				m.note("[%s] %s = %v", table, key, tables[table][key])
+			}
+		}
+	}
+	return m, nil
+}
+
+// migrateFresh converts a Fresh runner.conf
+func migrateFresh(content string) *migration {
+	settings := map[string]string{
+		"root":        ".",
+		"tmp_path":    "./tmp",
+		"build_name":  "runner-build",
+		"valid_ext":   ".go, .tpl, .tmpl, .html",
+		"ignored":     "assets, tmp",
+		"build_delay": "600",
+	}
+	m := &migration{vai: &Vai{Jobs: map[string]Job{}}}
+	for line := range strings.Lines(content) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "root", "tmp_path", "build_name", "valid_ext", "ignored", "build_delay":
+			settings[key] = value
+		default:
+			m.note("%s: %s", key, value)
+		}
+	}
+
+	root := settings["root"]
+	bin := path.Join(settings["tmp_path"], settings["build_name"])
+	if !path.IsAbs(bin) {
+		bin = "./" + bin
+	}
+	job := Job{
+		Series: []Job{
+			{Cmd: "go", Params: []string{"build", "-o", bin, root}},
+			{Cmd: bin},
+		},
+		Trigger: &Trigger{Paths: []string{root}},
+	}
+	var exts []string
+	for _, ext := range splitList(settings["valid_ext"]) {
+		exts = append(exts, strings.TrimPrefix(ext, "."))
+	}
+	switch len(exts) {
+	case 0:
+	case 1:
+		job.Trigger.Glob = append(job.Trigger.Glob, "**/*."+exts[0])
+	default:
+		job.Trigger.Glob = append(job.Trigger.Glob, "**/*.{"+strings.Join(exts, ",")+"}")
+	}
+	for _, dir := range splitList(settings["ignored"]) {
+		job.Trigger.Glob = append(job.Trigger.Glob, "!"+path.Join(dir, "**"))
+	}
+	if delay, err := strconv.Atoi(settings["build_delay"]); err == nil && delay > 0 {
+		job.Trigger.Batch = time.Duration(delay) * time.Millisecond
+	}
+	m.vai.Jobs["app"] = job
+	return m
+}
+
+// migrateModd converts a modd.conf, each block becomes a job
+func migrateModd(content string) (*migration, error) {
+	m := &migration{vai: &Vai{Jobs: map[string]Job{}}}
+
+	type block struct {
+		patterns []string
+		preps    []Job
+		daemons  []Job
+	}
+	var blocks []block
+	var current *block
+	var pending []string
+
+	for _, line := range joinContinued(content) {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if current == nil {
+			if strings.HasPrefix(line, "@") {
+				m.note("variable %s", line)
+				continue
+			}
+			before, after, open := strings.Cut(line, "{")
+			pending = append(pending, shellFields(before)...)
+			if !open {
+				continue
+			}
+			current = &block{patterns: pending}
+			pending = nil
+			line = strings.TrimSpace(after)
+			if line == "" {
+				continue
+			}
+		}
+		if line == "}" {
+			blocks = append(blocks, *current)
+			current = nil
+			continue
+		}
+
+		kind, cmd, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("unexpected line in block: %s", line)
+		}
+		kind, options, _ := strings.Cut(strings.TrimSpace(kind), " ")
+		cmd = unquote(strings.TrimSpace(cmd))
+		if options != "" {
+			m.note("%s option %s on '%s'", kind, strings.TrimSpace(options), cmd)
+		}
+		switch kind {
+		case "prep":
+			current.preps = append(current.preps, commandStep(cmd))
+		case "daemon":
+			current.daemons = append(current.daemons, commandStep(cmd))
+		default:
+			m.note("%s: %s", kind, cmd)
+		}
+	}
+	if current != nil {
+		return nil, errors.New("unclosed block")
+	}
+
+	for i, b := range blocks {
+		job := Job{Trigger: &Trigger{Paths: []string{"."}}}
+		for _, p := range b.patterns {
+			job.Trigger.Glob = append(job.Trigger.Glob, strings.TrimPrefix(p, "./"))
+		}
+		steps := b.preps
+		switch len(b.daemons) {
+		case 0:
+		case 1:
+			steps = append(steps, b.daemons[0])
+		default:
+			steps = append(steps, Job{Parallel: b.daemons})
+		}
+		if len(steps) == 1 {
+			job.Cmd, job.Params = steps[0].Cmd, steps[0].Params
+		} else {
+			job.Series = steps
+		}
+		m.vai.Jobs[migratedName(i, len(blocks))] = job
+	}
+	return m, nil
+}
+
+// migrateReflex converts a reflex config, each line becomes a job
+func migrateReflex(content string) (*migration, error) {
+	m := &migration{vai: &Vai{Jobs: map[string]Job{}}}
+
+	var lines []string
+	for _, line := range joinContinued(content) {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+
+	for i, line := range lines {
+		fields := shellFields(line)
+		if len(fields) > 0 && fields[0] == "reflex" {
+			fields = fields[1:]
+		}
+		trigger := &Trigger{Paths: []string{"."}}
+		// Reflex only runs commands on change unless started as a service
+		start := ChangeOnly
+
+		j := 0
+		for j < len(fields) && strings.HasPrefix(fields[j], "-") {
+			flag, value, hasValue := strings.Cut(fields[j], "=")
+			if flag == "--" {
+				j++
+				break
+			}
+			takesValue := slices.Contains([]string{"-r", "--regex", "-R", "--inverse-regex", "-g", "--glob", "-G", "--inverse-glob", "-t", "--shutdown-timeout", "-d", "--decoration"}, flag)
+			if takesValue && !hasValue && j+1 < len(fields) {
+				j++
+				value = fields[j]
+			}
+			switch flag {
+			case "-r", "--regex":
+				trigger.Regex = append(trigger.Regex, value)
+			case "-R", "--inverse-regex":
+				trigger.Regex = append(trigger.Regex, "!"+value)
+			case "-g", "--glob":
+				trigger.Glob = append(trigger.Glob, value)
+			case "-G", "--inverse-glob":
+				trigger.Glob = append(trigger.Glob, "!"+value)
+			case "-s", "--start-service":
+				start = StartAndChange
+			default:
+				if takesValue {
+					m.note("%s %s", flag, value)
+				} else {
+					m.note("%s", flag)
+				}
+			}
+			j++
+		}
+
+		cmd := strings.Join(fields[j:], " ")
+		if cmd == "" {
+			return nil, fmt.Errorf("line %d has no command", i+1)
+		}
+		if strings.Contains(cmd, "{}") {
+			m.note("{} placeholder in '%s', the changed files are in $VAI_CHANGED_FILES", cmd)
+		}
+		job := commandStep(cmd)
+		job.Trigger = trigger
+		job.RunOnStart = start
+		m.vai.Jobs[migratedName(i, len(lines))] = job
+	}
+	return m, nil
+}
+
+// commandStep turns a command line into a job, going through a shell when it needs one
+func commandStep(cmd string) Job {
+	if strings.ContainsAny(cmd, "|&;<>$`*(){}") {
+		return Job{Cmd: "sh", Params: []string{"-c", cmd}}
+	}
+	fields := shellFields(cmd)
+	if len(fields) == 0 {
+		return Job{}
+	}
+	return Job{Cmd: fields[0], Params: fields[1:]}
+}
+
+// splitEnv separates the leading KEY=VALUE assignments of a command line
+func splitEnv(cmd string) (map[string]string, string) {
+	fields := shellFields(cmd)
+	env := make(map[string]string)
+	i := 0
+	for ; i < len(fields); i++ {
+		key, value, ok := strings.Cut(fields[i], "=")
+		if !ok || key == "" || strings.ContainsAny(key, "/.") {
+			break
+		}
+		env[key] = value
+	}
+	if len(env) == 0 {
+		return nil, cmd
+	}
+	return env, strings.Join(fields[i:], " ")
+}
+
+// shellFields splits a command line on spaces, keeping quoted strings together
+func shellFields(s string) []string {
+	var fields []string
+	var b strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			b.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		default:
+			b.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
+// joinContinued reads the lines of a file, joining the ones ending with a backslash
+func joinContinued(content string) []string {
+	var lines []string
+	var current strings.Builder
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if trimmed, ok := strings.CutSuffix(strings.TrimRight(line, " \t"), `\`); ok {
+			current.WriteString(trimmed)
+			current.WriteString(" ")
+			continue
+		}
+		current.WriteString(line)
+		lines = append(lines, current.String())
+		current.Reset()
+	}
+	if current.Len() > 0 {
+		lines = append(lines, current.String())
+	}
+	return lines
+}
+
+// splitList splits a comma-separated setting
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// unquote removes the quotes around a command
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// migratedName names the jobs converted from a list
+func migratedName(i, total int) string {
+	if total == 1 {
+		return "app"
+	}
+	return fmt.Sprintf("app-%d", i+1)
+}
+
+// parseTOML reads the tables of a TOML file, top-level values are in the "" table
+func parseTOML(content string) (map[string]map[string]any, error) {
+	var doc map[string]any
+	if _, err := toml.Decode(content, &doc); err != nil {
+		return nil, err
+	}
+	tables := map[string]map[string]any{"": {}}
+	for key, value := range doc {
+		if table, ok := value.(map[string]any); ok {
+			tables[key] = table
+			continue
+		}
+		tables[""][key] = value
+	}
+	return tables, nil
+}
+
+// tomlString returns a string value of a table
+func tomlString(table map[string]any, key, fallback string) string {
+	if s, ok := table[key].(string); ok {
+		return s
+	}
+	return fallback
+}
+
+// tomlStrings returns a list of strings of a table
+func tomlStrings(table map[string]any, key string, fallback []string) []string {
+	items, ok := table[key].([]any)
+	if !ok {
+		return fallback
+	}
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// tomlInt returns a number of a table
+func tomlInt(table map[string]any, key string, fallback int) int {
+	switch n := table[key].(type) {
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return fallback
+}
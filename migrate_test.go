@@ -0,0 +1,227 @@
+package main
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateAir(t *testing.T) {
+	m, err := migrateAir(`
+root = "."
+tmp_dir = "tmp"
+
+[build]
+  # Build the binary
+  cmd = "go build -o ./tmp/main ./cmd/api"
+  full_bin = "APP_ENV=dev ./tmp/main"
+  args_bin = ["--port", "8080"]
+  pre_cmd = ["templ generate"]
+  include_ext = ["go", "templ"]
+  exclude_dir = [
+    "tmp",
+    "node_modules", # frontend
+  ]
+  exclude_regex = ["_test\\.go"]
+  delay = 1000
+  exclude_unchanged = false
+  kill_delay = "0s"
+  poll = true
+
+[screen]
+  clear_on_rebuild = true
+
+[color]
+  main = "magenta"
+`)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	job := m.vai.Jobs["app"]
+	expected := []Job{
+		{Cmd: "go", Params: []string{"build", "-o", "./tmp/main", "./cmd/api"}},
+		{Cmd: "./tmp/main", Params: []string{"--port", "8080"}},
+	}
+	if !reflect.DeepEqual(job.Series, expected) {
+		t.Errorf("Expected steps %+v, got %+v", expected, job.Series)
+	}
+	if !reflect.DeepEqual(job.Env, map[string]string{"APP_ENV": "dev"}) {
+		t.Errorf("Expected the env of full_bin, got %v", job.Env)
+	}
+	if len(job.Before) != 1 || job.Before[0].Cmd != "templ" {
+		t.Errorf("Expected pre_cmd as a before step, got %+v", job.Before)
+	}
+	if expected := []string{"**/*.{go,templ}", "!tmp/**", "!node_modules/**"}; !reflect.DeepEqual(job.Trigger.Glob, expected) {
+		t.Errorf("Expected globs %v, got %v", expected, job.Trigger.Glob)
+	}
+	if expected := []string{`!_test\.go`}; !reflect.DeepEqual(job.Trigger.Regex, expected) {
+		t.Errorf("Expected regex %v, got %v", expected, job.Trigger.Regex)
+	}
+	if job.Trigger.Batch != time.Second {
+		t.Errorf("Expected a 1s batch, got %v", job.Trigger.Batch)
+	}
+	if m.vai.Config.Watcher != "poll" || !m.vai.Config.ClearCli || !m.vai.Config.DisableHashCheck {
+		t.Errorf("Expected the watcher options, got %+v", m.vai.Config)
+	}
+	if expected := []string{"[build] kill_delay = 0s", "[color] main = magenta"}; !reflect.DeepEqual(m.notes, expected) {
+		t.Errorf("Expected notes %v, got %v", expected, m.notes)
+	}
+}
+
+func TestMigrateFresh(t *testing.T) {
+	m := migrateFresh(`
+root:              .
+tmp_path:          ./tmp
+build_name:        server
+valid_ext:         .go, .html
+ignored:           assets, tmp
+build_delay:       200
+colors:            1
+`)
+	job := m.vai.Jobs["app"]
+	if job.Series[0].Cmd != "go" || job.Series[1].Cmd != "./tmp/server" {
+		t.Errorf("Expected a build and a run step, got %+v", job.Series)
+	}
+	if expected := []string{"**/*.{go,html}", "!assets/**", "!tmp/**"}; !reflect.DeepEqual(job.Trigger.Glob, expected) {
+		t.Errorf("Expected globs %v, got %v", expected, job.Trigger.Glob)
+	}
+	if job.Trigger.Batch != 200*time.Millisecond {
+		t.Errorf("Expected a 200ms batch, got %v", job.Trigger.Batch)
+	}
+	if expected := []string{"colors: 1"}; !reflect.DeepEqual(m.notes, expected) {
+		t.Errorf("Expected notes %v, got %v", expected, m.notes)
+	}
+}
+
+func TestMigrateModd(t *testing.T) {
+	m, err := migrateModd(`
+@shell = bash
+
+**/*.go !**/*_test.go {
+    prep: go build -o ./bin/api ./cmd/api
+    daemon +sigterm: ./bin/api
+}
+
+# Tests
+**/*.go {
+    prep: go test \
+        ./...
+}
+`)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	names := slices.Sorted(maps.Keys(m.vai.Jobs))
+	if expected := []string{"app-1", "app-2"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected jobs %v, got %v", expected, names)
+	}
+	api := m.vai.Jobs["app-1"]
+	if len(api.Series) != 2 || api.Series[1].Cmd != "./bin/api" {
+		t.Errorf("Expected prep then daemon, got %+v", api.Series)
+	}
+	if expected := []string{"**/*.go", "!**/*_test.go"}; !reflect.DeepEqual(api.Trigger.Glob, expected) {
+		t.Errorf("Expected globs %v, got %v", expected, api.Trigger.Glob)
+	}
+	test := m.vai.Jobs["app-2"]
+	if test.Cmd != "go" || !reflect.DeepEqual(test.Params, []string{"test", "./..."}) {
+		t.Errorf("Expected a single command job, got %+v", test)
+	}
+	if len(m.notes) != 2 {
+		t.Errorf("Expected the variable and the +sigterm option to be noted, got %v", m.notes)
+	}
+
+	if _, err := migrateModd("**/*.go {\n  prep: go test\n"); err == nil {
+		t.Error("Expected an error for an unclosed block")
+	}
+}
+
+func TestMigrateReflex(t *testing.T) {
+	m, err := migrateReflex(`
+-r '\.go$' -R '_test\.go$' -s -- go run .
+--glob='*.md' --decoration=none echo {}
+`)
+	if err != nil {
+		t.Fatalf("migrate failed: %v", err)
+	}
+
+	run := m.vai.Jobs["app-1"]
+	if run.Cmd != "go" || !reflect.DeepEqual(run.Params, []string{"run", "."}) {
+		t.Errorf("Expected go run, got %+v", run)
+	}
+	if expected := []string{`\.go$`, `!_test\.go$`}; !reflect.DeepEqual(run.Trigger.Regex, expected) {
+		t.Errorf("Expected regex %v, got %v", expected, run.Trigger.Regex)
+	}
+	if run.RunOnStart != StartAndChange {
+		t.Errorf("Expected the -s service to run on start, got %v", run.RunOnStart.value())
+	}
+	docs := m.vai.Jobs["app-2"]
+	if docs.Cmd != "sh" || !reflect.DeepEqual(docs.Trigger.Glob, []string{"*.md"}) {
+		t.Errorf("Expected a shell command on markdown files, got %+v", docs)
+	}
+	if docs.RunOnStart != ChangeOnly {
+		t.Errorf("Expected a command without -s to run on change only, got %v", docs.RunOnStart.value())
+	}
+	if len(m.notes) != 2 || !strings.Contains(m.notes[1], "VAI_CHANGED_FILES") {
+		t.Errorf("Expected the decoration and the placeholder to be noted, got %v", m.notes)
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.WriteFile(".air.toml", []byte("[build]\n  cmd = \"go build -o ./tmp/main .\"\n  send_interrupt = true\n"), 0644)
+
+	args := &Args{Command: "migrate", ConfigFile: "vai.yml"}
+	if code := runMigrate(args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "vai.yml"))
+	content := string(data)
+	if !strings.Contains(content, "# Migrated from .air.toml") || !strings.Contains(content, "#   - [build] send_interrupt = true") {
+		t.Errorf("Expected the source and the untranslated settings in comments, got:\n%s", content)
+	}
+	if errs, _ := splitWarnings(validateConfig("vai.yml", data)); len(errs) > 0 {
+		t.Errorf("Migrated config is invalid: %v\n%s", errs, content)
+	}
+
+	if code := runMigrate(args); code != 1 {
+		t.Error("Expected an existing config not to be overwritten")
+	}
+	args.Force = true
+	if code := runMigrate(args); code != 0 {
+		t.Error("Expected --force to overwrite the config")
+	}
+}
+
+func TestParseTOML(t *testing.T) {
+	tables, err := parseTOML(`
+title = 'literal # not a comment'
+[a]
+list = ["x", "y,z"] # comment
+n = 1_000
+f = 0.5
+`)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if tables[""]["title"] != "literal # not a comment" {
+		t.Errorf("Unexpected title %v", tables[""]["title"])
+	}
+	if !reflect.DeepEqual(tables["a"]["list"], []any{"x", "y,z"}) || tables["a"]["n"] != int64(1000) || tables["a"]["f"] != 0.5 {
+		t.Errorf("Unexpected table %v", tables["a"])
+	}
+
+	for _, bad := range []string{"key", "list = [1, 2", "key = \"open", "key = nope"} {
+		if _, err := parseTOML(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// runOnce runs jobs one after the other without watching, stopping at the first failure
+func runOnce(args *Args) int {
+	if !fileExists(args.ConfigFile) {
+		logger.log(SeverityError, OpError, "No config file %s to run jobs from", args.ConfigFile)
+		return 1
+	}
+	v, err := newVai(args)
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to initialize Vai: %v", err)
+		return 1
+	}
+	logger.setLevel(parseSeverity(v.Config.Severity))
+
+	// All jobs by default
+	names := args.Jobs
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(v.Jobs))
+	}
+	for _, name := range names {
+		if _, ok := v.Jobs[name]; !ok {
+			if s := closest(name, slices.Collect(maps.Keys(v.Jobs))); s != "" {
+				logger.log(SeverityError, OpError, "Unknown job '%s', did you mean '%s'?", name, s)
+			} else {
+				logger.log(SeverityError, OpError, "Unknown job '%s'", name)
+			}
+			return 1
+		}
+	}
+
+	// Stop the running job on Ctrl+C
+	var interrupted atomic.Bool
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sigChan:
+			interrupted.Store(true)
+			v.manager.stop()
+		case <-done:
+		}
+	}()
+
+	for _, name := range names {
+		if code := v.runJob(name, &interrupted); code != 0 {
+			return code
+		}
+	}
+	return 0
+}
+
+// runJob runs a job once and returns its exit code
+func (v *Vai) runJob(name string, interrupted *atomic.Bool) int {
+	job := v.Jobs[name]
+	job.Name = name
+	logger.log(SeverityWarn, OpInfo, "Running job %s", cyan(name))
+
+	ctx, deregister := v.manager.register(name)
+	defer deregister()
+	ctx = context.WithValue(ctx, failFastCtxKey{}, true)
+	if v.args.DryRun {
+		ctx = context.WithValue(ctx, dryRunCtxKey{}, true)
+	}
+
+	started := time.Now()
+	err := job.start(ctx)
+	duration := cyan(time.Since(started).Round(time.Millisecond))
+	switch {
+	case interrupted.Load():
+		logger.log(SeverityError, OpError, "Job %s interrupted", red(name))
+		return 130
+	case err != nil:
+		logger.log(SeverityError, OpError, "Job %s failed (%s)", red(name), duration)
+		return 1
+	}
+	logger.log(SeverityWarn, OpSuccess, "Job %s done (%s)", green(name), duration)
+	return 0
+}
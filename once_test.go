@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRunOnce(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping executor tests on Windows due to shell command differences")
+	}
+	resetGlobals()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.WriteFile("vai.yml", []byte(`jobs:
+  ok:
+    series:
+      - cmd: touch
+        params: [ok]
+  fail:
+    series:
+      - cmd: "false"
+      - cmd: touch
+        params: [skipped]
+`), 0644)
+
+	if code := runOnce(mustParseArgs(t, []string{"run", "ok"})); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ok")); err != nil {
+		t.Error("Expected the job to run")
+	}
+
+	if code := runOnce(mustParseArgs(t, []string{"run", "ok", "fail"})); code != 1 {
+		t.Errorf("Expected exit code 1, got %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "skipped")); err == nil {
+		t.Error("Expected the step after the failing one not to run")
+	}
+
+	if code := runOnce(mustParseArgs(t, []string{"run", "okk"})); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown job, got %d", code)
+	}
+}
+
+func TestRunOnce_NoConfig(t *testing.T) {
+	resetGlobals()
+	t.Chdir(t.TempDir())
+	if code := runOnce(mustParseArgs(t, []string{"run"})); code != 1 {
+		t.Errorf("Expected exit code 1 without a config, got %d", code)
+	}
+}
+
+func TestParseArgs_Run(t *testing.T) {
+	cli := mustParseArgs(t, []string{"run", "build", "test"})
+	if cli.Command != "run" || len(cli.Jobs) != 2 || cli.Jobs[1] != "test" {
+		t.Errorf("Expected two jobs to run, got %+v", cli)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// fileState is the polled state of a file
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+	hash    string
+}
+
+// poller is a watcher that periodically scans the watched paths
+type poller struct {
+	interval time.Duration
+	hash     bool
+	exclude  []*regexp.Regexp
+	skip     func(path string) bool
+	events   chan fswatcher.WatchEvent
+	errors   chan error
+	mu       sync.Mutex
+	roots    map[string]map[string]fileState
+	running  atomic.Bool
+	nextID   atomic.Uint64
+}
+
+// newPoller creates a polling watcher, skip reports directories that shouldn't be scanned
+func newPoller(interval time.Duration, hash bool, bufferSize int, excRegex []string, skip func(string) bool) (*poller, error) {
+	p := &poller{
+		interval: interval,
+		hash:     hash,
+		skip:     skip,
+		events:   make(chan fswatcher.WatchEvent, bufferSize),
+		errors:   make(chan error, 16),
+		roots:    make(map[string]map[string]fileState),
+	}
+	for _, rx := range excRegex {
+		re, err := regexp.Compile(rx)
+		if err != nil {
+			return nil, err
+		}
+		p.exclude = append(p.exclude, re)
+	}
+	return p, nil
+}
+
+// Watch polls the watched paths until the context is canceled
+func (p *poller) Watch(ctx context.Context) error {
+	if !p.running.CompareAndSwap(false, true) {
+		return errors.New("poller is already running")
+	}
+	defer p.running.Store(false)
+	defer close(p.events)
+
+	logger.log(SeverityDebug, OpInfo, "Polling watcher started, interval %s", p.interval)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			p.poll()
+		}
+	}
+}
+
+// AddPath starts polling a path
+func (p *poller) AddPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.roots[abs]; ok {
+		return errors.New("path is already being watched")
+	}
+	p.roots[abs] = p.scan(abs)
+	return nil
+}
+
+// DropPath stops polling a path
+func (p *poller) DropPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.roots[abs]; !ok {
+		return errors.New("path is not being watched")
+	}
+	delete(p.roots, abs)
+	return nil
+}
+
+// Events returns the channel for receiving file events
+func (p *poller) Events() <-chan fswatcher.WatchEvent { return p.events }
+
+// Errors returns the channel of watch errors
+func (p *poller) Errors() <-chan error { return p.errors }
+
+// Paths returns the watched paths
+func (p *poller) Paths() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	paths := make([]string, 0, len(p.roots))
+	for path := range p.roots {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// poll rescans every watched path and emits the differences
+func (p *poller) poll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for root, old := range p.roots {
+		current := p.scan(root)
+		for path, st := range current {
+			prev, ok := old[path]
+			switch {
+			case !ok:
+				p.emit(path, fswatcher.EventCreate)
+			case p.changed(prev, st):
+				p.emit(path, fswatcher.EventMod)
+			case prev.mode != st.mode:
+				p.emit(path, fswatcher.EventChmod)
+			}
+		}
+		for path := range old {
+			if _, ok := current[path]; !ok {
+				p.emit(path, fswatcher.EventRemove)
+			}
+		}
+		p.roots[root] = current
+	}
+}
+
+// changed compares two states of a file, by content if hashing is enabled
+func (p *poller) changed(prev, st fileState) bool {
+	if p.hash {
+		return prev.hash != st.hash
+	}
+	return !prev.modTime.Equal(st.modTime) || prev.size != st.size
+}
+
+// emit sends an event without blocking the poll loop
+func (p *poller) emit(path string, t fswatcher.EventType) {
+	ev := fswatcher.WatchEvent{
+		ID:    p.nextID.Add(1),
+		Path:  path,
+		Types: []fswatcher.EventType{t},
+		Time:  time.Now(),
+	}
+	select {
+	case p.events <- ev:
+	default:
+		select {
+		case p.errors <- fmt.Errorf("event dropped for %s, buffer full", path):
+		default:
+		}
+	}
+}
+
+// scan walks a path and records the state of every file
+func (p *poller) scan(root string) map[string]fileState {
+	files := make(map[string]fileState)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && d.Name() == ".git" {
+				p.scanGit(path, files)
+				return fs.SkipDir
+			}
+			if path != root && p.skip != nil && p.skip(path) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if p.excluded(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[path] = p.state(path, info)
+		return nil
+	})
+	return files
+}
+
+// scanGit records the files marking git operations, the rest of .git is left out
+func (p *poller) scanGit(dir string, files map[string]fileState) {
+	names := []string{"HEAD", gitIndexLock}
+	for _, f := range gitStateFiles {
+		names = append(names, f.name)
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil {
+			files[path] = p.state(path, info)
+		}
+	}
+}
+
+// state builds the polled state of a file
+func (p *poller) state(path string, info fs.FileInfo) fileState {
+	st := fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+	if p.hash && info.Mode().IsRegular() {
+		st.hash = hashFile(path)
+	}
+	return st
+}
+
+// excluded checks the path and its base name against the exclusion patterns
+func (p *poller) excluded(path string) bool {
+	base := filepath.Base(path)
+	for _, re := range p.exclude {
+		if re.MatchString(path) || re.MatchString(base) {
+			return true
+		}
+	}
+	return false
+}
+
+// hashFile returns the hex sha256 of a file content
+func hashFile(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package main
+
+import "syscall"
+
+// remoteFSTypes are network filesystems known not to deliver inotify events for remote changes.
+// FUSE is left out, its magic is shared by local filesystems like encfs and rclone caches
+var remoteFSTypes = map[uint32]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x01021997: "9p",
+	0x786f4256: "vboxsf",
+}
+
+// remoteFS returns the filesystem type of a path if it needs polling
+func remoteFS(path string) (string, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return "", false
+	}
+	name, ok := remoteFSTypes[uint32(st.Type)]
+	return name, ok
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+// remoteFS returns the filesystem type of a path if it needs polling
+func remoteFS(path string) (string, bool) {
+	// Not detected outside Linux
+	return "", false
+}
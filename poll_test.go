@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// nextEvent waits for the next event of a watcher
+func nextEvent(t *testing.T, w eventSource) fswatcher.WatchEvent {
+	t.Helper()
+	select {
+	case ev := <-w.Events():
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for an event")
+	}
+	return fswatcher.WatchEvent{}
+}
+
+// noEvent checks that no event is emitted for a few poll cycles
+func noEvent(t *testing.T, w eventSource) {
+	t.Helper()
+	select {
+	case ev := <-w.Events():
+		t.Fatalf("Unexpected event: %s", ev)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPoller(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "node_modules"), 0755)
+
+	p, err := newPoller(10*time.Millisecond, false, 64, []string{`\.tmp$`}, func(path string) bool {
+		return strings.HasSuffix(path, "node_modules")
+	})
+	if err != nil {
+		t.Fatalf("newPoller failed: %v", err)
+	}
+	if err := p.AddPath(dir); err != nil {
+		t.Fatalf("AddPath failed: %v", err)
+	}
+	if err := p.AddPath(dir); err == nil {
+		t.Error("Expected an error adding the same path twice")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		p.Watch(ctx)
+		close(done)
+	}()
+
+	file := filepath.Join(dir, "main.go")
+	expect := func(want fswatcher.EventType) {
+		t.Helper()
+		ev := nextEvent(t, p)
+		if ev.Path != file || len(ev.Types) != 1 || ev.Types[0] != want {
+			t.Fatalf("Expected %s on %s, got %s", want, file, ev)
+		}
+	}
+
+	os.WriteFile(file, []byte("package main"), 0644)
+	expect(fswatcher.EventCreate)
+
+	os.WriteFile(file, []byte("package main\n"), 0644)
+	expect(fswatcher.EventMod)
+
+	if runtime.GOOS != "windows" {
+		os.Chmod(file, 0600)
+		expect(fswatcher.EventChmod)
+	}
+
+	os.Remove(file)
+	expect(fswatcher.EventRemove)
+
+	// Excluded files and skipped directories are not reported
+	os.WriteFile(filepath.Join(dir, "x.tmp"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(dir, "node_modules", "index.js"), []byte("x"), 0644)
+	noEvent(t, p)
+
+	cancel()
+	<-done
+	if _, ok := <-p.Events(); ok {
+		t.Error("Expected the events channel to be closed")
+	}
+}
+
+func TestPoller_GitState(t *testing.T) {
+	dir := t.TempDir()
+	gitDir := filepath.Join(dir, ".git")
+	os.MkdirAll(filepath.Join(gitDir, "objects"), 0755)
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+
+	p, _ := newPoller(10*time.Millisecond, false, 64, nil, nil)
+	p.AddPath(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Watch(ctx)
+
+	// Git internals are not reported
+	os.WriteFile(filepath.Join(gitDir, "objects", "ab"), []byte("x"), 0644)
+	noEvent(t, p)
+
+	// The files marking operations are, for the git guard
+	lock := filepath.Join(gitDir, "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	if ev := nextEvent(t, p); ev.Path != lock || ev.Types[0] != fswatcher.EventCreate {
+		t.Errorf("Expected index.lock to be created, got %s", ev)
+	}
+	os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644)
+	if ev := nextEvent(t, p); ev.Path != filepath.Join(gitDir, "HEAD") || ev.Types[0] != fswatcher.EventMod {
+		t.Errorf("Expected HEAD to change, got %s", ev)
+	}
+}
+
+func TestPoller_Hash(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "main.go")
+	os.WriteFile(file, []byte("package main"), 0644)
+
+	p, _ := newPoller(10*time.Millisecond, true, 64, nil, nil)
+	p.AddPath(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Watch(ctx)
+
+	// Same content with a new mtime is not a change
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(file, later, later)
+	noEvent(t, p)
+
+	os.WriteFile(file, []byte("package app"), 0644)
+	if ev := nextEvent(t, p); ev.Types[0] != fswatcher.EventMod {
+		t.Errorf("Expected a modify event, got %s", ev)
+	}
+}
+
+func TestUsePolling(t *testing.T) {
+	v := &Vai{cwd: t.TempDir(), Config: Config{Watcher: "poll"}}
+	if !v.usePolling() {
+		t.Error("Expected polling when configured")
+	}
+	v.Config.Watcher = "native"
+	if v.usePolling() {
+		t.Error("Expected native watcher when configured")
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 500 * time.Millisecond
+
+// watchConfig signals a reload whenever the content of the config file changes
+func (v *Vai) watchConfig(ctx context.Context) {
+	last := hashFile(v.config)
+
+	ticker := time.NewTicker(configPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Editors may save by removing and renaming, wait for the new file
+			sum := hashFile(v.config)
+			if sum == "" || sum == last {
+				continue
+			}
+			last = sum
+			select {
+			case v.reloads <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// isConfig reports whether a path is the active config file
+func (v *Vai) isConfig(path string) bool {
+	if v.config == "" {
+		return false
+	}
+	abs, canonical := resolvePath(path)
+	config, canonicalConfig := resolvePath(v.config)
+	return abs == config || canonical == canonicalConfig
+}
+
+// reload parses the config file again and applies the job changes, the running config is kept if it's invalid
+func (v *Vai) reload() {
+	logger.log(SeverityWarn, OpInfo, "Config %s changed, reloading", v.config)
+
+	next := &Vai{cwd: v.cwd, args: v.args, config: v.config}
+	if err := next.load(); err != nil {
+		logger.log(SeverityError, OpError, "Keeping the running config: %v", err)
+		return
+	}
+
+	// Stop removed jobs and restart changed ones
+	var stopped, restarted, started []string
+	for name := range v.Jobs {
+		if _, ok := next.Jobs[name]; !ok {
+			stopped = append(stopped, name)
+		}
+	}
+	for name, job := range next.Jobs {
+		old, ok := v.Jobs[name]
+		switch {
+		case !ok:
+			started = append(started, name)
+		case jobChanged(old, job):
+			restarted = append(restarted, name)
+		}
+	}
+	slices.Sort(stopped)
+	slices.Sort(restarted)
+	slices.Sort(started)
+
+	restartWatch := !v.sameWatch(next)
+	v.jobsMu.Lock()
+	v.Jobs = next.Jobs
+	v.jobsMu.Unlock()
+	v.Config = next.Config
+	logger.setLevel(parseSeverity(v.Config.Severity))
+
+	for _, name := range stopped {
+		logger.log(SeverityWarn, OpWarn, "Stopping removed job: %s", name)
+		v.batcher.cancel(name)
+		v.manager.stopJob(name)
+	}
+	// Changed and new jobs start like at launch, the others wait for a change
+	for _, name := range restarted {
+		v.batcher.cancel(name)
+		if !v.runsOnStart(v.Jobs[name]) {
+			// The running process belongs to a definition that no longer exists
+			logger.log(SeverityWarn, OpInfo, "Stopping changed job until its next change: %s", name)
+			v.manager.stopJob(name)
+			continue
+		}
+		logger.log(SeverityWarn, OpWarn, "Restarting changed job: %s", name)
+		v.trigger(name, v.Jobs[name], nil)
+	}
+	for _, name := range started {
+		if !v.runsOnStart(v.Jobs[name]) {
+			logger.log(SeverityWarn, OpInfo, "New job waits for its first change: %s", name)
+			continue
+		}
+		logger.log(SeverityWarn, OpWarn, "Starting new job: %s", name)
+		v.trigger(name, v.Jobs[name], nil)
+	}
+	if len(stopped)+len(restarted)+len(started) == 0 {
+		logger.log(SeverityInfo, OpSuccess, "Config reloaded, no job changed")
+	}
+
+	// Paths, exclusions and watcher options are set when the watcher is created
+	if restartWatch && v.restart != nil {
+		v.restart()
+	}
+}
+
+// jobChanged compares two job definitions
+func jobChanged(old, job Job) bool {
+	a, errA := yaml.Marshal(old)
+	b, errB := yaml.Marshal(job)
+	return errA != nil || errB != nil || string(a) != string(b)
+}
+
+// sameWatch reports whether the watcher can be kept for another config
+func (v *Vai) sameWatch(next *Vai) bool {
+	return reflect.DeepEqual(v.Config, next.Config) &&
+		slices.Equal(v.watchPaths(), next.watchPaths())
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	config := filepath.Join(dir, "vai.yml")
+	write := func(content string) {
+		if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+jobs:
+  removed:
+    cmd: echo
+    trigger:
+      paths: ["` + dir + `"]
+  changed:
+    cmd: go
+    params: [build]
+    trigger:
+      paths: ["` + dir + `"]
+  kept:
+    cmd: go
+    params: [test]
+    trigger:
+      paths: ["` + dir + `"]
+`)
+
+	v := &Vai{cwd: dir, config: config, manager: newManager()}
+	if err := v.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	var launched []string
+	v.launch = func(name string, _ Job, _ []string) {
+		launched = append(launched, name)
+	}
+	restarted := false
+	v.restart = func() { restarted = true }
+
+	write(`
+jobs:
+  changed:
+    cmd: go
+    params: [build, ./...]
+    trigger:
+      paths: ["` + dir + `"]
+  kept:
+    cmd: go
+    params: [test]
+    trigger:
+      paths: ["` + dir + `"]
+  added:
+    cmd: go
+    params: [vet]
+    trigger:
+      paths: ["` + dir + `"]
+`)
+	v.reload()
+
+	slices.Sort(launched)
+	if expected := []string{"added", "changed"}; !reflect.DeepEqual(launched, expected) {
+		t.Errorf("Expected %v to be started, got %v", expected, launched)
+	}
+	if _, ok := v.Jobs["removed"]; ok {
+		t.Error("Expected the removed job to be gone")
+	}
+	if v.Jobs["changed"].Trigger.matcher == nil {
+		t.Error("Expected the new triggers to be compiled")
+	}
+	if restarted {
+		t.Error("Expected the watcher to be kept when paths don't change")
+	}
+
+	t.Run("invalid config keeps the running one", func(t *testing.T) {
+		launched = nil
+		write(`
+jobs:
+  changed:
+    cmd: go
+    trigger:
+      events: [explode]
+`)
+		v.reload()
+		if len(launched) != 0 || len(v.Jobs) != 3 {
+			t.Errorf("Expected the running jobs to be kept, launched %v", launched)
+		}
+	})
+
+	t.Run("severity changes only once the config is valid", func(t *testing.T) {
+		logger = newLogger(SeverityError)
+		defer func() { logger = newLogger(SeverityError) }()
+
+		// Logging goroutines run while the level is swapped
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for range 100 {
+				logger.log(SeverityDebug, OpInfo, "still logging")
+			}
+		}()
+		defer func() { <-done }()
+
+		write(`
+config:
+  severity: debug
+jobs:
+  kept:
+    cmd: go
+    trigger:
+      regex: ["("]
+`)
+		v.reload()
+		if level := Severity(logger.level.Load()); level != SeverityError {
+			t.Errorf("Expected the log level of the running config, got %s", level)
+		}
+
+		write(`
+config:
+  severity: info
+jobs:
+  kept:
+    cmd: go
+    params: [test]
+    trigger:
+      paths: ["` + dir + `"]
+`)
+		v.reload()
+		if level := Severity(logger.level.Load()); level != SeverityInfo {
+			t.Errorf("Expected the reloaded log level, got %s", level)
+		}
+	})
+
+	t.Run("new paths restart the watcher", func(t *testing.T) {
+		sub := filepath.Join(dir, "sub")
+		os.Mkdir(sub, 0755)
+		write(`
+jobs:
+  kept:
+    cmd: go
+    params: [test]
+    trigger:
+      paths: ["` + sub + `"]
+`)
+		v.reload()
+		if !restarted {
+			t.Error("Expected the watcher to be restarted")
+		}
+	})
+}
+
+func TestReload_StartMode(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	config := filepath.Join(dir, "vai.yml")
+	write := func(content string) {
+		if err := os.WriteFile(config, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`
+jobs:
+  lint:
+    cmd: golangci-lint
+    trigger:
+      paths: ["` + dir + `"]
+`)
+	v := &Vai{cwd: dir, config: config, args: &Args{NoInitialRun: true}, manager: newManager()}
+	if err := v.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	var launched []string
+	v.launch = func(name string, _ Job, _ []string) {
+		launched = append(launched, name)
+	}
+	running, deregister := v.manager.register("lint")
+	defer deregister()
+
+	// Changed and new jobs follow runOnStart and --no-initial-run like at launch
+	write(`
+jobs:
+  lint:
+    cmd: golangci-lint
+    params: [run]
+    runOnStart: false
+    trigger:
+      paths: ["` + dir + `"]
+  setup:
+    cmd: go
+    params: [mod, download]
+    runOnStart: only
+  test:
+    cmd: go
+    params: [test]
+    trigger:
+      paths: ["` + dir + `"]
+`)
+	v.reload()
+	if expected := []string{"setup"}; !reflect.DeepEqual(launched, expected) {
+		t.Errorf("Expected only %v to start, got %v", expected, launched)
+	}
+	if running.Err() == nil {
+		t.Error("Expected the old run of the changed job to be stopped")
+	}
+}
+
+func TestIsConfig(t *testing.T) {
+	dir := t.TempDir()
+	v := &Vai{config: filepath.Join(dir, "vai.yml")}
+	if !v.isConfig(filepath.Join(dir, ".", "vai.yml")) {
+		t.Error("Expected the config file to be recognized")
+	}
+	if v.isConfig(filepath.Join(dir, "main.go")) {
+		t.Error("Expected other files not to be the config")
+	}
+	if (&Vai{}).isConfig(filepath.Join(dir, "vai.yml")) {
+		t.Error("Expected no config file in CLI mode")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runSave writes the configuration given by the config file and the CLI flags, without running it
+func runSave(args *Args) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		logger.log(SeverityError, OpError, "Could not get current working directory: %v", err)
+		return 1
+	}
+
+	// Same layering as a normal start, without the defaults
+	v := &Vai{cwd: cwd, args: args}
+	if fileExists(args.ConfigFile) {
+		if err := v.applyConfig(args.ConfigFile); err != nil {
+			logger.log(SeverityError, OpError, "Failed to load config file: %v", err)
+			return 1
+		}
+	}
+	v.applyCLI(args)
+	if len(v.Jobs) == 0 {
+		logger.log(SeverityError, OpError, "Nothing to save, provide a command or a config file")
+		return 1
+	}
+
+	exists := fileExists(args.SaveFile)
+	if exists && !args.Force {
+		logger.log(SeverityError, OpError, "%s already exists, use --force to update it", args.SaveFile)
+		return 1
+	}
+
+	v.relativePaths()
+	if exists {
+		err = v.update(args.SaveFile)
+	} else {
+		err = v.save(args.SaveFile)
+	}
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to save config file: %v", err)
+		return 1
+	}
+	fmt.Printf("%s %s\n", green("Saved"), args.SaveFile)
+	return 0
+}
+
+// relativePaths rewrites absolute trigger paths relative to the working directory, the one they are loaded from
+func (v *Vai) relativePaths() {
+	for name, job := range v.Jobs {
+		if job.Trigger == nil {
+			continue
+		}
+		trigger := *job.Trigger
+		trigger.Paths = make([]string, len(job.Trigger.Paths))
+		for i, p := range job.Trigger.Paths {
+			trigger.Paths[i] = p
+			if !filepath.IsAbs(p) {
+				continue
+			}
+			if rel, err := filepath.Rel(v.cwd, p); err == nil {
+				trigger.Paths[i] = filepath.ToSlash(rel)
+			}
+		}
+		job.Trigger = &trigger
+		v.Jobs[name] = job
+	}
+}
+
+// update writes the configuration into an existing file, keeping its comments and key order
+func (v *Vai) update(filePath string) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("%s: %v", filePath, err)
+	}
+	if len(doc.Content) == 0 {
+		return v.save(filePath)
+	}
+
+	var next yaml.Node
+	if err := next.Encode(v); err != nil {
+		return err
+	}
+	mergeNode(doc.Content[0], &next)
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, b.Bytes(), 0644)
+}
+
+// mergeNode updates dst to the values of src, keeping the comments and the order of dst
+func mergeNode(dst, src *yaml.Node) {
+	dst = resolveAlias(dst)
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		values := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			values[src.Content[i].Value] = src.Content[i+1]
+		}
+		var content []*yaml.Node
+		kept := make(map[string]bool)
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key, value := dst.Content[i], dst.Content[i+1]
+			next, ok := values[key.Value]
+			// Omitted zero values mean the same thing as the ones written
+			if !ok && !isZeroNode(resolveAlias(value)) {
+				continue
+			}
+			if ok {
+				mergeNode(value, next)
+			}
+			content = append(content, key, value)
+			kept[key.Value] = true
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key, value := src.Content[i], src.Content[i+1]
+			if kept[key.Value] || isZeroNode(value) {
+				continue
+			}
+			content = append(content, key, value)
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i, item := range src.Content {
+			if i < len(dst.Content) {
+				mergeNode(dst.Content[i], item)
+				continue
+			}
+			dst.Content = append(dst.Content, item)
+		}
+		dst.Content = dst.Content[:len(src.Content)]
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Value != src.Value {
+			dst.Value, dst.Tag, dst.Style = src.Value, src.Tag, src.Style
+		}
+	case dst.Kind == yaml.ScalarNode && sameJob(dst, src):
+		// A job written as a command line stays one
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// sameJob reports whether two nodes describe the same job
+func sameJob(a, b *yaml.Node) bool {
+	var ja, jb Job
+	if a.Decode(&ja) != nil || b.Decode(&jb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(ja, jb)
+}
+
+// isZeroNode reports whether a node holds a value that is omitted when saving
+func isZeroNode(node *yaml.Node) bool {
+	switch node.Kind {
+	case yaml.MappingNode, yaml.SequenceNode:
+		return len(node.Content) == 0
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return true
+		case "!!bool":
+			return node.Value == "false"
+		case "!!int", "!!float":
+			return node.Value == "0"
+		case "!!str":
+			return node.Value == "" || node.Value == "0s"
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunSave(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	os.Mkdir("app", 0755)
+
+	args := mustParseArgs(t, []string{"--path=" + filepath.Join(dir, "app"), "--save=dev.yml", "go", "run", "./app"})
+	if code := runSave(args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	v, err := fromFile("dev.yml")
+	if err != nil {
+		t.Fatalf("Saved config can't be read: %v", err)
+	}
+	job := v.Jobs["default"]
+	if len(job.Series) != 1 || job.Series[0].Cmd != "go" {
+		t.Errorf("Expected the CLI command, got %+v", job.Series)
+	}
+	if job.Trigger.Paths[0] != "app" {
+		t.Errorf("Expected the path relative to the working directory, got %v", job.Trigger.Paths)
+	}
+
+	if code := runSave(args); code != 1 {
+		t.Error("Expected an existing file not to be overwritten")
+	}
+
+	// Paths are loaded from the working directory, not from the directory of the file
+	os.Mkdir("config", 0755)
+	args = mustParseArgs(t, []string{"--path=app", "--save=config/dev.yml", "go", "run", "./app"})
+	if code := runSave(args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	v, err = fromFile(filepath.Join("config", "dev.yml"))
+	if err != nil {
+		t.Fatalf("Saved config can't be read: %v", err)
+	}
+	if paths := v.Jobs["default"].Trigger.Paths; len(paths) != 1 || paths[0] != "app" {
+		t.Errorf("Expected the path to stay relative to the working directory, got %v", paths)
+	}
+}
+
+func TestRunSave_Update(t *testing.T) {
+	resetGlobals()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	original := `# Project config
+config:
+  clearCli: false # explicit
+jobs:
+  # Tests first
+  test: go test ./...
+  default:
+    cmd: go
+    params: [build]
+`
+	os.WriteFile("vai.yml", []byte(original), 0644)
+
+	args := mustParseArgs(t, []string{"--force", "--save", "go", "run", "."})
+	if code := runSave(args); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	data, _ := os.ReadFile("vai.yml")
+	content := string(data)
+	for _, expected := range []string{"# Project config", "clearCli: false # explicit", "# Tests first", "test: go test ./..."} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("Expected %q to be kept, got:\n%s", expected, content)
+		}
+	}
+	if strings.Index(content, "test:") > strings.Index(content, "default:") {
+		t.Errorf("Expected the key order to be kept, got:\n%s", content)
+	}
+
+	v, err := fromFile("vai.yml")
+	if err != nil {
+		t.Fatalf("Updated config can't be read: %v", err)
+	}
+	job := v.Jobs["default"]
+	if job.Cmd != "" || len(job.Series) != 1 || strings.Join(job.Series[0].Params, " ") != "run ." {
+		t.Errorf("Expected the CLI job to replace the default one, got %+v", job)
+	}
+	if job.Trigger.Paths[0] != "." {
+		t.Errorf("Expected the working directory as '.', got %v", job.Trigger.Paths)
+	}
+}
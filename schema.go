@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// schemaURL is where the published schema of vai.yml is served
+const schemaURL = "https://raw.githubusercontent.com/sgtdi/vai/main/vai.schema.json"
+
+// schemaHeader tells the YAML language server which schema applies to a file
+const schemaHeader = "# yaml-language-server: $schema=" + schemaURL + "\n"
+
+var (
+	watcherModes   = []string{"auto", "native", "poll"}
+	severityLevels = []string{"debug", "info", "warn", "warning", "error"}
+)
+
+// schemaDescriptions documents the keys of each type in the schema
+var schemaDescriptions = map[string]map[string]string{
+	"config": {
+		"severity":         "Log level",
+		"clearCli":         "Clear the terminal before each run",
+		"cooldown":         "Minimum time between two events of the same file",
+		"bufferSize":       "Size of the watcher event buffer",
+		"batchingDuration": "Group watcher events received within this duration",
+		"disableHashCheck": "Trigger on every event, even if the file content didn't change",
+		"maxSelfRestarts":  "Pause a job after this many restarts caused by its own writes, -1 disables",
+		"respectGitignore": "Skip files ignored by .gitignore and .git/info/exclude",
+		"watcher":          "File watcher backend, poll is used automatically on network filesystems with auto",
+		"pollInterval":     "Scan interval of the polling watcher",
+		"pollHash":         "Compare file contents instead of modification times when polling",
+	},
+	"job": {
+		"name":       "Name of the step in logs",
+		"cmd":        "Command to run",
+		"params":     "Arguments of the command",
+		"series":     "Steps run one after the other",
+		"parallel":   "Steps run at the same time",
+		"before":     "Steps run before the job",
+		"after":      "Steps run after the job",
+		"env":        "Environment variables",
+		"inputs":     "Globs hashed to skip the step when they didn't change",
+		"outputs":    "Files that must exist for the step to be skipped",
+		"trigger":    "Files that trigger the job",
+		"runOnStart": "Run the job on start, false waits for a change, only runs it once on start and never on change",
+	},
+	"trigger": {
+		"paths":      "Paths to watch",
+		"regex":      "Regex patterns on the path relative to each trigger path, prefix with ! to exclude",
+		"glob":       "Glob patterns on the path relative to each trigger path, prefix with ! to exclude",
+		"events":     "Events that trigger the job, all by default",
+		"ignoreSelf": "Ignore events while the job is running",
+		"cooldown":   "Minimum time between two runs",
+		"batch":      "Collect events during this window and run once",
+	},
+}
+
+// schema generates the JSON Schema of vai.yml from the config types
+func schema() map[string]any {
+	config := structSchema(reflect.TypeFor[Config](), "config")
+	props := config["properties"].(map[string]any)
+	props["watcher"].(map[string]any)["enum"] = watcherModes
+	props["severity"].(map[string]any)["enum"] = severityLevels
+
+	job := structSchema(reflect.TypeFor[Job](), "job")
+	job["properties"].(map[string]any)["name"] = describe(map[string]any{"type": "string"}, "job", "name")
+
+	trigger := structSchema(reflect.TypeFor[Trigger](), "trigger")
+	events := slices.Sorted(maps.Keys(eventTypes))
+	trigger["properties"].(map[string]any)["events"].(map[string]any)["items"] = map[string]any{
+		"type": "string",
+		"enum": events,
+	}
+
+	return map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  schemaURL,
+		"title":                "vai.yml",
+		"description":          "Vai configuration file",
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties": map[string]any{
+			"config": map[string]any{"$ref": "#/$defs/config"},
+			"jobs": map[string]any{
+				"description":          "Jobs by name",
+				"type":                 "object",
+				"additionalProperties": map[string]any{"$ref": "#/$defs/job"},
+			},
+		},
+		"$defs": map[string]any{
+			"config": config,
+			"job": map[string]any{
+				// A job is either a command line or a map
+				"oneOf": []any{
+					map[string]any{"type": "string", "description": "Command line to run"},
+					job,
+				},
+			},
+			"trigger":  trigger,
+			"duration": map[string]any{"type": "string", "pattern": `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`},
+		},
+	}
+}
+
+// schemaJSON returns the indented schema
+func schemaJSON() ([]byte, error) {
+	data, err := json.MarshalIndent(schema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// runSchema prints the schema and returns the exit code
+func runSchema() int {
+	data, err := schemaJSON()
+	if err != nil {
+		logger.log(SeverityError, OpError, "Failed to generate schema: %v", err)
+		return 1
+	}
+	fmt.Print(string(data))
+	return 0
+}
+
+// structSchema describes the yaml fields of a struct
+func structSchema(t reflect.Type, name string) map[string]any {
+	props := make(map[string]any)
+	for key, ft := range yamlFields(t) {
+		props[key] = describe(typeSchema(ft), name, key)
+	}
+	return map[string]any{
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           props,
+	}
+}
+
+// typeSchema describes a field type
+func typeSchema(t reflect.Type) map[string]any {
+	switch {
+	case t == reflect.TypeFor[time.Duration]():
+		return map[string]any{"$ref": "#/$defs/duration"}
+	case t == reflect.TypeFor[StartMode]():
+		return map[string]any{"enum": []any{true, false, "only"}}
+	case t == jobType:
+		return map[string]any{"$ref": "#/$defs/job"}
+	case t == triggerType:
+		return map[string]any{"$ref": "#/$defs/trigger"}
+	case t.Kind() == reflect.Pointer:
+		return typeSchema(t.Elem())
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() == reflect.Int:
+		return map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	}
+	return map[string]any{"type": "string"}
+}
+
+// describe adds the description of a key to its schema
+func describe(s map[string]any, name, key string) map[string]any {
+	if d, ok := schemaDescriptions[name][key]; ok {
+		s["description"] = d
+	}
+	return s
+}
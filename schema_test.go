@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestSchema_InSync(t *testing.T) {
+	data, err := schemaJSON()
+	if err != nil {
+		t.Fatalf("schemaJSON failed: %v", err)
+	}
+	committed, err := os.ReadFile("vai.schema.json")
+	if err != nil {
+		t.Fatalf("Failed to read vai.schema.json: %v", err)
+	}
+	if !bytes.Equal(data, committed) {
+		t.Error("vai.schema.json is out of date, regenerate it with `go run . schema > vai.schema.json`")
+	}
+}
+
+func TestSchema_Types(t *testing.T) {
+	var s map[string]any
+	data, _ := schemaJSON()
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+	defs := s["$defs"].(map[string]any)
+
+	// Jobs accept a command string or a map
+	oneOf := defs["job"].(map[string]any)["oneOf"].([]any)
+	if len(oneOf) != 2 || oneOf[0].(map[string]any)["type"] != "string" || oneOf[1].(map[string]any)["type"] != "object" {
+		t.Errorf("Expected a job to be a string or an object, got %v", oneOf)
+	}
+	job := oneOf[1].(map[string]any)["properties"].(map[string]any)
+	for _, key := range []string{"cmd", "params", "series", "parallel", "before", "after", "env", "inputs", "outputs", "trigger", "name"} {
+		if _, ok := job[key]; !ok {
+			t.Errorf("Expected job property %q", key)
+		}
+	}
+	if ref := job["series"].(map[string]any)["items"].(map[string]any)["$ref"]; ref != "#/$defs/job" {
+		t.Errorf("Expected series steps to be jobs, got %v", ref)
+	}
+
+	trigger := defs["trigger"].(map[string]any)["properties"].(map[string]any)
+	if ref := trigger["cooldown"].(map[string]any)["$ref"]; ref != "#/$defs/duration" {
+		t.Errorf("Expected cooldown to be a duration, got %v", ref)
+	}
+	events := trigger["events"].(map[string]any)["items"].(map[string]any)["enum"].([]any)
+	if len(events) != len(eventTypes) {
+		t.Errorf("Expected %d trigger events, got %v", len(eventTypes), events)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// eventSource produces the file events dispatched by vai
+type eventSource interface {
+	// Watch starts producing events and blocks until the context is canceled
+	Watch(ctx context.Context) error
+	// AddPath adds a path to watch at runtime
+	AddPath(path string) error
+	// DropPath removes a path from watching at runtime
+	DropPath(path string) error
+	// Events returns the channel of file events, closed when watching stops
+	Events() <-chan fswatcher.WatchEvent
+	// Errors returns the channel of watch errors
+	Errors() <-chan error
+	// Paths returns the watched paths
+	Paths() []string
+}
+
+// fswatcherSource adapts a fswatcher.Watcher to an eventSource
+type fswatcherSource struct {
+	w      fswatcher.Watcher
+	errors chan error
+}
+
+// newFswatcherSource creates the native event source
+func newFswatcherSource(opts ...fswatcher.WatcherOpt) (*fswatcherSource, error) {
+	w, err := fswatcher.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &fswatcherSource{w: w, errors: make(chan error, 16)}, nil
+}
+
+// Watch starts the native watcher and reports dropped events as errors
+func (s *fswatcherSource) Watch(ctx context.Context) error {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case ev := <-s.w.Dropped():
+				select {
+				case s.errors <- fmt.Errorf("event dropped for %s, buffer full", ev.Path):
+				default:
+				}
+			}
+		}
+	}()
+	return s.w.Watch(ctx)
+}
+
+// AddPath adds a path to the native watcher
+func (s *fswatcherSource) AddPath(path string) error { return s.w.AddPath(path) }
+
+// DropPath removes a path from the native watcher
+func (s *fswatcherSource) DropPath(path string) error { return s.w.DropPath(path) }
+
+// Events returns the native watcher events
+func (s *fswatcherSource) Events() <-chan fswatcher.WatchEvent { return s.w.Events() }
+
+// Errors returns the native watcher errors
+func (s *fswatcherSource) Errors() <-chan error { return s.errors }
+
+// Paths returns the paths watched by the native watcher
+func (s *fswatcherSource) Paths() []string { return s.w.Paths() }
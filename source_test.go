@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sgtdi/fswatcher"
+)
+
+// fakeSource is a scriptable event source for tests
+type fakeSource struct {
+	mu     sync.Mutex
+	paths  []string
+	events chan fswatcher.WatchEvent
+	errors chan error
+}
+
+// newFakeSource creates a fake source with buffered channels
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		events: make(chan fswatcher.WatchEvent, 64),
+		errors: make(chan error, 8),
+	}
+}
+
+// Watch blocks until the context is canceled, then closes the events
+func (f *fakeSource) Watch(ctx context.Context) error {
+	<-ctx.Done()
+	close(f.events)
+	return nil
+}
+
+// AddPath records a watched path
+func (f *fakeSource) AddPath(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.paths = append(f.paths, path)
+	return nil
+}
+
+// DropPath forgets a watched path
+func (f *fakeSource) DropPath(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, p := range f.paths {
+		if p == path {
+			f.paths = append(f.paths[:i], f.paths[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("path is not being watched")
+}
+
+func (f *fakeSource) Events() <-chan fswatcher.WatchEvent { return f.events }
+func (f *fakeSource) Errors() <-chan error                { return f.errors }
+
+func (f *fakeSource) Paths() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.paths...)
+}
+
+// emit injects an event for a path
+func (f *fakeSource) emit(path string, types ...fswatcher.EventType) {
+	f.events <- fswatcher.WatchEvent{Path: path, Types: types, Time: time.Now()}
+}
+
+// dispatched records the jobs launched by a Vai instead of running them
+func dispatched(v *Vai) func() []string {
+	var mu sync.Mutex
+	var names []string
+	v.launch = func(name string, _ Job, files []string) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, f := range files {
+			names = append(names, name+":"+filepath.Base(f))
+		}
+	}
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), names...)
+	}
+}
+
+func TestRunEventLoop(t *testing.T) {
+	resetGlobals()
+	root := t.TempDir()
+	src := newFakeSource()
+
+	v := &Vai{
+		cwd:     root,
+		manager: newManager(),
+		source:  src,
+		Config:  Config{DisableHashCheck: true},
+		Jobs: map[string]Job{
+			"build": {Cmd: "go", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"**/*.go"}}},
+			"css":   {Cmd: "sass", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"*.css"}, Events: []string{"create"}}},
+		},
+	}
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	got := dispatched(v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		v.runEventLoop(ctx)
+		close(done)
+	}()
+
+	src.emit(filepath.Join(root, "main.go"), fswatcher.EventMod)
+	src.emit(filepath.Join(root, "style.css"), fswatcher.EventMod)
+	src.emit(filepath.Join(root, "README.md"), fswatcher.EventCreate)
+	src.emit(filepath.Join(root, "style.css"), fswatcher.EventCreate)
+	src.emit(filepath.Join(root, "pkg", "util.go"), fswatcher.EventCreate)
+	close(src.events)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Event loop did not stop when the source closed")
+	}
+
+	expected := []string{"build:main.go", "css:style.css", "build:util.go"}
+	if result := got(); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected dispatches %v, got %v", expected, result)
+	}
+}
+
+func TestRunEventLoop_Chmod(t *testing.T) {
+	resetGlobals()
+	root, _ := filepath.EvalSymlinks(t.TempDir())
+	main := filepath.Join(root, "main.go")
+	script := filepath.Join(root, "run.sh")
+	os.WriteFile(main, []byte("package main"), 0644)
+	os.WriteFile(script, []byte("#!/bin/sh"), 0644)
+
+	src := newFakeSource()
+	v := &Vai{
+		cwd:     root,
+		manager: newManager(),
+		source:  src,
+		Jobs: map[string]Job{
+			"build": {Cmd: "go", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"**/*.go"}}},
+			"perms": {Cmd: "ls", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"*.sh"}, Events: []string{"chmod"}}},
+		},
+	}
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	v.seedHashes([]string{root})
+	got := dispatched(v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		v.runEventLoop(ctx)
+		close(done)
+	}()
+
+	// The content check still drops metadata-only events of other jobs
+	os.Chmod(script, 0755)
+	src.emit(main, fswatcher.EventChmod)
+	src.emit(script, fswatcher.EventChmod)
+	close(src.events)
+	<-done
+
+	if expected := []string{"perms:run.sh"}; !reflect.DeepEqual(got(), expected) {
+		t.Errorf("Expected dispatches %v, got %v", expected, got())
+	}
+}
+
+func TestStartWatch_Source(t *testing.T) {
+	resetGlobals()
+	root := t.TempDir()
+	src := newFakeSource()
+
+	v := &Vai{
+		cwd:     root,
+		manager: newManager(),
+		source:  src,
+		Jobs: map[string]Job{
+			"build": {Cmd: "go", Trigger: &Trigger{Paths: []string{root}}},
+		},
+	}
+	dispatched(v)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		v.startWatch(ctx)
+		close(done)
+	}()
+
+	// Trigger paths are added while the source is watching
+	deadline := time.Now().Add(2 * time.Second)
+	for len(src.Paths()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("startWatch did not return after cancel")
+	}
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{root}) {
+		t.Errorf("Expected the trigger path to be watched, got %v", paths)
+	}
+}
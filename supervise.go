@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// superviseInterval is how often the trigger paths are checked
+const superviseInterval = time.Second
+
+// supervisedPath is a trigger path and the directory currently watched for it
+type supervisedPath struct {
+	path    string
+	abs     string
+	info    os.FileInfo
+	removed bool
+	lastErr string
+}
+
+// pathSupervisor keeps the trigger paths watched when they're missing, removed or recreated
+type pathSupervisor struct {
+	source   eventSource
+	paths    []*supervisedPath
+	interval time.Duration
+	watching []string
+}
+
+// newPathSupervisor creates a supervisor for the given trigger paths
+func newPathSupervisor(source eventSource, paths []string) *pathSupervisor {
+	s := &pathSupervisor{source: source, interval: superviseInterval}
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			abs = p
+		}
+		s.paths = append(s.paths, &supervisedPath{path: p, abs: abs})
+	}
+	// Parents first, their recursive watch covers the paths inside them
+	slices.SortStableFunc(s.paths, func(a, b *supervisedPath) int {
+		return len(a.abs) - len(b.abs)
+	})
+	return s
+}
+
+// covered reports whether a path is inside another watched path
+func covered(watched map[string]struct{}, abs string) bool {
+	for p := range watched {
+		if rel, ok := within(p, abs); ok && rel != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// run checks the trigger paths until the context is canceled
+func (s *pathSupervisor) run(ctx context.Context) {
+	s.check()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check()
+		}
+	}
+}
+
+// check watches paths that appeared or were recreated and forgets removed ones
+func (s *pathSupervisor) check() {
+	watched := make(map[string]struct{})
+	for _, p := range s.source.Paths() {
+		watched[p] = struct{}{}
+	}
+
+	for _, p := range s.paths {
+		// Watching it again would race with the watch of its parent
+		if _, ok := watched[p.abs]; !ok && covered(watched, p.abs) {
+			continue
+		}
+		info, err := os.Stat(p.abs)
+		switch {
+		case err != nil && p.info != nil:
+			// Removed, the watch on the old directory is gone
+			s.fail(p, fmt.Sprintf("Watched path %s was removed, waiting for it to reappear", p.path))
+			_ = s.source.DropPath(p.abs)
+			p.info, p.removed = nil, true
+		case os.IsNotExist(err):
+			if p.lastErr == "" {
+				s.fail(p, fmt.Sprintf("Path %s doesn't exist yet, waiting for it", p.path))
+			}
+		case err != nil:
+			s.fail(p, fmt.Sprintf("Failed to watch path %s: %v", p.path, err))
+		case p.info == nil:
+			if _, ok := watched[p.abs]; ok && !p.removed {
+				// Already watched by the source
+				p.info = info
+				continue
+			}
+			if p.removed {
+				// Some watchers can't drop a path while it's missing
+				_ = s.source.DropPath(p.abs)
+			}
+			if err := s.source.AddPath(p.abs); err != nil {
+				s.fail(p, fmt.Sprintf("Failed to watch path %s: %v", p.path, err))
+				continue
+			}
+			if p.lastErr != "" {
+				logger.log(SeverityInfo, OpSuccess, "Watching path %s", p.path)
+			}
+			watched[p.abs] = struct{}{}
+			p.info, p.removed, p.lastErr = info, false, ""
+		case !os.SameFile(p.info, info):
+			// Recreated between two checks, watch the new directory
+			logger.log(SeverityWarn, OpWarn, "Watched path %s was recreated, watching it again", p.path)
+			_ = s.source.DropPath(p.abs)
+			if err := s.source.AddPath(p.abs); err != nil {
+				s.fail(p, fmt.Sprintf("Failed to watch path %s: %v", p.path, err))
+				p.info = nil
+				continue
+			}
+			p.info = info
+		}
+	}
+
+	// Report the watch set when it changes
+	current := s.source.Paths()
+	slices.Sort(current)
+	if !slices.Equal(current, s.watching) {
+		s.watching = current
+		logger.log(SeverityDebug, OpInfo, "Watching %d paths: %v", len(current), current)
+	}
+}
+
+// fail logs a path problem once until it changes
+func (s *pathSupervisor) fail(p *supervisedPath, msg string) {
+	if msg == p.lastErr {
+		return
+	}
+	p.lastErr = msg
+	logger.log(SeverityWarn, OpWarn, "%s", msg)
+}
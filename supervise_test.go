@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathSupervisor(t *testing.T) {
+	resetGlobals()
+	root := t.TempDir()
+	src := newFakeSource()
+	existing := filepath.Join(root, "src")
+	generated := filepath.Join(root, "generated")
+	os.Mkdir(existing, 0755)
+
+	s := newPathSupervisor(src, []string{existing, generated})
+
+	// Missing paths are retried until they exist
+	s.check()
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{existing}) {
+		t.Fatalf("Expected only the existing path to be watched, got %v", paths)
+	}
+	os.Mkdir(generated, 0755)
+	s.check()
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{existing, generated}) {
+		t.Fatalf("Expected the new path to be watched, got %v", paths)
+	}
+
+	// Removed paths are dropped and re-added when they reappear
+	os.RemoveAll(generated)
+	s.check()
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{existing}) {
+		t.Fatalf("Expected the removed path to be dropped, got %v", paths)
+	}
+	os.Mkdir(generated, 0755)
+	s.check()
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{existing, generated}) {
+		t.Fatalf("Expected the path to be watched again, got %v", paths)
+	}
+
+	// A directory recreated between two checks is watched again
+	os.Mkdir(existing+".new", 0755)
+	os.RemoveAll(existing)
+	os.Rename(existing+".new", existing)
+	before := s.paths[0].info
+	s.check()
+	if paths := src.Paths(); len(paths) != 2 {
+		t.Fatalf("Expected both paths to be watched, got %v", paths)
+	}
+	if info, _ := os.Stat(existing); !os.SameFile(s.paths[0].info, info) || os.SameFile(before, info) {
+		t.Error("Expected the recreated directory to be tracked")
+	}
+}
+
+func TestPathSupervisor_Nested(t *testing.T) {
+	resetGlobals()
+	root := t.TempDir()
+	src := newFakeSource()
+	cmd := filepath.Join(root, "cmd")
+	os.Mkdir(cmd, 0755)
+
+	// The nested path comes first, its parent still covers it
+	s := newPathSupervisor(src, []string{cmd, root})
+	s.check()
+	s.check()
+	if paths := src.Paths(); !reflect.DeepEqual(paths, []string{root}) {
+		t.Errorf("Expected only the parent to be watched, got %v", paths)
+	}
+}
+
+func TestOutermost(t *testing.T) {
+	root := t.TempDir()
+	paths := []string{filepath.Join(root, "cmd"), root, filepath.Join(root, "cmd", "api"), root + "-other", root}
+	if expected := []string{root, root + "-other"}; !reflect.DeepEqual(outermost(paths), expected) {
+		t.Errorf("Expected %v, got %v", expected, outermost(paths))
+	}
+}
@@ -0,0 +1,417 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configError is a problem found in a config file, at a line and column
+type configError struct {
+	file    string
+	line    int
+	column  int
+	msg     string
+	warning bool
+}
+
+// Error formats the error as file:line:column: message
+func (e *configError) Error() string {
+	if e.warning {
+		return fmt.Sprintf("%s:%d:%d: warning: %s", e.file, e.line, e.column, e.msg)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.file, e.line, e.column, e.msg)
+}
+
+// splitWarnings separates the warnings, which don't prevent vai from starting
+func splitWarnings(all []error) (errs, warnings []error) {
+	for _, err := range all {
+		if ce, ok := err.(*configError); ok && ce.warning {
+			warnings = append(warnings, err)
+			continue
+		}
+		errs = append(errs, err)
+	}
+	return errs, warnings
+}
+
+// validator walks the YAML nodes of a config file and collects errors
+type validator struct {
+	file string
+	errs []error
+}
+
+var (
+	jobType     = reflect.TypeFor[Job]()
+	jobsType    = reflect.TypeFor[[]Job]()
+	triggerType = reflect.TypeFor[*Trigger]()
+)
+
+// runValidate prints the errors of a config file and returns the exit code
+func runValidate(path string) int {
+	all := validateFile(path)
+	for _, err := range all {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if errs, _ := splitWarnings(all); len(errs) > 0 {
+		return 1
+	}
+	fmt.Printf("%s %s\n", path, green("is valid"))
+	return 0
+}
+
+// validateFile checks a config file and returns every error found
+func validateFile(path string) []error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	return validateConfig(displayPath(path), data)
+}
+
+// validateConfig checks the content of a config file, errors are reported under the given name
+func validateConfig(file string, data []byte) []error {
+	v := &validator{file: file}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return []error{syntaxError(file, err)}
+	}
+	if len(doc.Content) == 0 {
+		return []error{fmt.Errorf("%s: config file is empty", file)}
+	}
+
+	root := resolveAlias(doc.Content[0])
+	fields := v.mapping(root, reflect.TypeFor[Vai](), "config file")
+	if cfg, ok := fields["config"]; ok {
+		v.config(cfg)
+	}
+	jobs, ok := fields["jobs"]
+	if !ok || jobs.Tag == "!!null" {
+		return v.sorted()
+	}
+	if jobs.Kind != yaml.MappingNode {
+		v.errorf(jobs, "jobs must be a map of job names")
+		return v.sorted()
+	}
+	v.keys(jobs, "job")
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		name := jobs.Content[i].Value
+		v.job(resolveAlias(jobs.Content[i+1]), fmt.Sprintf("job '%s'", name))
+	}
+	return v.sorted()
+}
+
+// yamlLine matches the position yaml reports for syntax errors
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// syntaxError reports a yaml syntax error at its line, yaml gives no column
+func syntaxError(file string, err error) error {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	line, _ := strconv.Atoi(m[1])
+	return &configError{file: file, line: line, column: 1, msg: m[2]}
+}
+
+// sorted returns the errors in file order
+func (v *validator) sorted() []error {
+	slices.SortStableFunc(v.errs, func(a, b error) int {
+		ca, cb := a.(*configError), b.(*configError)
+		if ca.line != cb.line {
+			return ca.line - cb.line
+		}
+		return ca.column - cb.column
+	})
+	return v.errs
+}
+
+// config checks the config section
+func (v *validator) config(node *yaml.Node) {
+	fields := v.mapping(node, reflect.TypeFor[Config](), "config")
+	if watcher, ok := fields["watcher"]; ok && !slices.Contains(watcherModes, watcher.Value) {
+		v.errorf(watcher, "unknown watcher '%s', use auto, native or poll", watcher.Value)
+	}
+	if severity, ok := fields["severity"]; ok && !slices.Contains(severityLevels, severity.Value) {
+		v.errorf(severity, "unknown severity '%s', use debug, info, warn or error", severity.Value)
+	}
+}
+
+// job checks a job or a step, given either as a command string or a map
+func (v *validator) job(node *yaml.Node, where string) {
+	if node.Kind == yaml.ScalarNode {
+		v.command(node, strings.Fields(node.Value))
+		return
+	}
+
+	fields := v.mapping(node, jobType, where)
+	if fields == nil {
+		return
+	}
+
+	var kinds []string
+	for _, key := range []string{"cmd", "series", "parallel"} {
+		if _, ok := fields[key]; ok {
+			kinds = append(kinds, "'"+key+"'")
+		}
+	}
+	if len(kinds) > 1 {
+		v.errorf(node, "%s can only contain one of 'cmd', 'series' or 'parallel', got %s", where, strings.Join(kinds, " and "))
+	}
+
+	if cmd, ok := fields["cmd"]; ok && cmd.Kind == yaml.ScalarNode {
+		v.program(cmd, where)
+	}
+	for _, key := range []string{"series", "parallel", "before", "after"} {
+		steps, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if steps.Kind != yaml.SequenceNode {
+			v.errorf(steps, "'%s' in %s must be a list of steps", key, where)
+			continue
+		}
+		for i, step := range steps.Content {
+			v.job(resolveAlias(step), fmt.Sprintf("%s %s step %d", where, key, i+1))
+		}
+	}
+	if trigger, ok := fields["trigger"]; ok {
+		v.trigger(trigger, where)
+	}
+}
+
+// trigger checks the trigger of a job
+func (v *validator) trigger(node *yaml.Node, where string) {
+	fields := v.mapping(node, triggerType.Elem(), "trigger of "+where)
+	if regex, ok := fields["regex"]; ok && regex.Kind == yaml.SequenceNode {
+		for _, rx := range regex.Content {
+			if _, err := regexp.Compile(strings.TrimPrefix(rx.Value, "!")); err != nil {
+				v.errorf(rx, "invalid regex '%s' in %s: %v", rx.Value, where, err)
+			}
+		}
+	}
+	if glob, ok := fields["glob"]; ok && glob.Kind == yaml.SequenceNode {
+		for _, g := range glob.Content {
+			if _, err := globToRegex(strings.TrimPrefix(g.Value, "!")); err != nil {
+				v.errorf(g, "invalid glob '%s' in %s: %v", g.Value, where, err)
+			}
+		}
+	}
+	if events, ok := fields["events"]; ok && events.Kind == yaml.SequenceNode {
+		for _, e := range events.Content {
+			if _, ok := eventTypes[strings.ToLower(e.Value)]; !ok {
+				v.errorf(e, "unknown trigger event '%s' in %s, use create, write, remove, rename or chmod", e.Value, where)
+			}
+		}
+	}
+}
+
+// program checks the 'cmd' of a map, which is run as is without splitting its arguments
+func (v *validator) program(node *yaml.Node, where string) {
+	name := node.Value
+	if strings.TrimSpace(name) == "" {
+		v.command(node, nil)
+		return
+	}
+	// Only an existing file may have spaces in its name
+	if _, err := os.Stat(name); err != nil && strings.ContainsAny(name, " \t") {
+		v.errorf(node, "'cmd' in %s is run as a single program '%s', put its arguments in 'params' or give the command as a string", where, name)
+		return
+	}
+	v.command(node, []string{name})
+}
+
+// command checks that the program of a command can be found
+func (v *validator) command(node *yaml.Node, parts []string) {
+	if len(parts) == 0 {
+		v.errorf(node, "empty command")
+		return
+	}
+	// Programs given by path may be built by a previous step
+	if strings.ContainsAny(parts[0], `/\`) {
+		return
+	}
+	// Tools may also be installed by a before step, so this is only a warning
+	if _, err := exec.LookPath(parts[0]); err != nil {
+		v.warnf(node, "command '%s' not found in PATH", parts[0])
+	}
+}
+
+// mapping checks the keys of a map against the yaml fields of a struct and the type of plain values
+func (v *validator) mapping(node *yaml.Node, t reflect.Type, where string) map[string]*yaml.Node {
+	node = resolveAlias(node)
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		return map[string]*yaml.Node{}
+	}
+	if node.Kind != yaml.MappingNode {
+		v.errorf(node, "%s must be a map", where)
+		return nil
+	}
+
+	known := yamlFields(t)
+	if t == jobType {
+		// Steps may be named
+		known["name"] = reflect.TypeFor[string]()
+	}
+	names := make([]string, 0, len(known))
+	for name := range known {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	v.keys(node, "key")
+	fields := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveAlias(node.Content[i+1])
+		ft, ok := known[key.Value]
+		if !ok {
+			msg := fmt.Sprintf("unknown field '%s' in %s", key.Value, where)
+			if s := closest(key.Value, names); s != "" {
+				msg += fmt.Sprintf(", did you mean '%s'?", s)
+			}
+			v.errorf(key, "%s", msg)
+			continue
+		}
+		fields[key.Value] = value
+
+		// Jobs and triggers are checked by their own walkers
+		if ft == jobsType || ft == triggerType || ft.Kind() == reflect.Struct || ft.Kind() == reflect.Map && ft.Elem() == jobType {
+			continue
+		}
+		if err := value.Decode(reflect.New(ft).Interface()); err != nil {
+			v.errorf(value, "invalid value '%s' for '%s' in %s, expected %s", value.Value, key.Value, where, typeName(ft))
+		}
+	}
+	return fields
+}
+
+// keys reports keys defined more than once in a map
+func (v *validator) keys(node *yaml.Node, what string) {
+	seen := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if first, ok := seen[key.Value]; ok {
+			v.errorf(key, "duplicate %s '%s', first defined at line %d", what, key.Value, first.Line)
+			continue
+		}
+		seen[key.Value] = key
+	}
+}
+
+// errorf records an error at the position of a node
+func (v *validator) errorf(node *yaml.Node, format string, args ...any) {
+	v.add(node, false, format, args...)
+}
+
+// warnf records a warning at the position of a node
+func (v *validator) warnf(node *yaml.Node, format string, args ...any) {
+	v.add(node, true, format, args...)
+}
+
+// add records a problem at the position of a node
+func (v *validator) add(node *yaml.Node, warning bool, format string, args ...any) {
+	v.errs = append(v.errs, &configError{
+		file:    v.file,
+		line:    node.Line,
+		column:  node.Column,
+		msg:     fmt.Sprintf(format, args...),
+		warning: warning,
+	})
+}
+
+// yamlFields maps the yaml keys of a struct to their field types
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// typeName describes the expected type of a value
+func typeName(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeFor[time.Duration]():
+		return "a duration like 100ms"
+	case t == reflect.TypeFor[StartMode]():
+		return "true, false or only"
+	case t.Kind() == reflect.Pointer:
+		return typeName(t.Elem())
+	case t.Kind() == reflect.Bool:
+		return "true or false"
+	case t.Kind() == reflect.Int:
+		return "an integer"
+	case t.Kind() == reflect.String:
+		return "a string"
+	case t.Kind() == reflect.Slice:
+		return "a list of strings"
+	case t.Kind() == reflect.Map:
+		return "a map of strings"
+	}
+	return t.String()
+}
+
+// closest returns the candidate within two edits of name, if any
+func closest(name string, candidates []string) string {
+	best, bestDist := "", 3
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// resolveAlias follows YAML aliases to the anchored node
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// displayPath returns a path relative to the working directory when possible
+func displayPath(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
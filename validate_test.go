@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "valid config",
+			content: `
+config:
+  cooldown: 200ms
+  watcher: poll
+jobs:
+  app:
+    cmd: go
+    params: [run, .]
+    env:
+      PORT: "8080"
+    trigger:
+      paths: [.]
+      glob: ["**/*.go"]
+      events: [create, write]
+  check: go vet ./...
+`,
+		},
+		{
+			name: "unknown key with suggestion",
+			content: `
+jobs:
+  app:
+    cmd: go
+    trigers:
+      paths: [.]
+`,
+			expected: []string{"vai.yml:5:5: unknown field 'trigers' in job 'app', did you mean 'trigger'?"},
+		},
+		{
+			name: "type errors",
+			content: `
+config:
+  bufferSize: large
+  clearCli: maybe
+jobs:
+  app:
+    cmd: go
+    params: build
+`,
+			expected: []string{
+				"vai.yml:3:15: invalid value 'large' for 'bufferSize' in config, expected an integer",
+				"vai.yml:4:13: invalid value 'maybe' for 'clearCli' in config, expected true or false",
+				"vai.yml:8:13: invalid value 'build' for 'params' in job 'app', expected a list of strings",
+			},
+		},
+		{
+			name: "run on start",
+			content: `
+jobs:
+  setup:
+    cmd: go
+    runOnStart: only
+  test:
+    cmd: go
+    runOnStart: later
+`,
+			expected: []string{"vai.yml:8:17: invalid value 'later' for 'runOnStart' in job 'test', expected true, false or only"},
+		},
+		{
+			name: "invalid patterns and events",
+			content: `
+jobs:
+  app:
+    cmd: go
+    trigger:
+      regex: ['(x']
+      glob: ['[a']
+      events: [save]
+`,
+			expected: []string{
+				"vai.yml:6:15: invalid regex '(x' in job 'app'",
+				"vai.yml:7:14: invalid glob '[a' in job 'app'",
+				"vai.yml:8:16: unknown trigger event 'save' in job 'app'",
+			},
+		},
+		{
+			name: "duplicate jobs and exclusive keys",
+			content: `
+jobs:
+  app:
+    cmd: go
+  app:
+    cmd: go
+    series: [go build]
+`,
+			expected: []string{
+				"vai.yml:5:3: duplicate job 'app', first defined at line 3",
+				"vai.yml:6:5: job 'app' can only contain one of 'cmd', 'series' or 'parallel'",
+			},
+		},
+		{
+			name: "nested steps",
+			content: `
+jobs:
+  app:
+    series:
+      - go build
+      - cmd: go
+        parms: [run]
+`,
+			expected: []string{"vai.yml:7:9: unknown field 'parms' in job 'app' series step 2, did you mean 'params'?"},
+		},
+		{
+			name: "map command with arguments",
+			content: `
+jobs:
+  app:
+    series:
+      - cmd: go build
+      - cmd: go
+        params: [run, .]
+`,
+			expected: []string{"vai.yml:5:14: 'cmd' in job 'app' series step 1 is run as a single program 'go build'"},
+		},
+		{
+			name: "missing command is a warning",
+			content: `
+jobs:
+  app: vai-missing-tool --flag
+`,
+			expected: []string{"vai.yml:3:8: warning: command 'vai-missing-tool' not found in PATH"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := validateConfig("vai.yml", []byte(tc.content))
+			if len(errs) != len(tc.expected) {
+				t.Fatalf("Expected %d errors, got %v", len(tc.expected), errs)
+			}
+			for i, err := range errs {
+				if !strings.HasPrefix(err.Error(), tc.expected[i]) {
+					t.Errorf("Expected error %q, got %q", tc.expected[i], err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Syntax(t *testing.T) {
+	errs := validateConfig("vai.yml", []byte("jobs:\n  app: go\n    cmd: go\n"))
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "vai.yml:3:1: ") {
+		t.Errorf("Expected a syntax error at line 3, got %v", errs)
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "vai.yml")
+	os.WriteFile(valid, []byte("jobs:\n  app: vai-missing-tool\n"), 0644)
+	invalid := filepath.Join(dir, "bad.yml")
+	os.WriteFile(invalid, []byte("jobs:\n  app:\n    cmd: go\n    trigger: {regex: ['(']}\n"), 0644)
+
+	var code int
+	captureOutput(func() { code = runValidate(valid) })
+	if code != 0 {
+		t.Errorf("Expected warnings alone to pass, got exit code %d", code)
+	}
+	captureOutput(func() { code = runValidate(invalid) })
+	if code != 1 {
+		t.Errorf("Expected exit code 1 for an invalid config, got %d", code)
+	}
+}
@@ -3,13 +3,16 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sgtdi/fswatcher"
@@ -18,11 +21,22 @@ import (
 
 // Vai contains vai fields
 type Vai struct {
-	cwd       string            `yaml:"-"`
-	Config    Config            `yaml:"config"`
-	Jobs      map[string]Job    `yaml:"jobs"`
-	manager   *Manager          `yaml:"-"`
-	fswatcher fswatcher.Watcher `yaml:"-"`
+	cwd     string                                     `yaml:"-"`
+	args    *Args                                      `yaml:"-"`
+	config  string                                     `yaml:"-"`
+	Config  Config                                     `yaml:"config"`
+	Jobs    map[string]Job                             `yaml:"jobs"`
+	manager *Manager                                   `yaml:"-"`
+	source  eventSource                                `yaml:"-"`
+	launch  func(name string, job Job, files []string) `yaml:"-"`
+	git     *gitGuard                                  `yaml:"-"`
+	hashes  map[string]string                          `yaml:"-"`
+	guard   *loopGuard                                 `yaml:"-"`
+	batcher *batcher                                   `yaml:"-"`
+	ignore  *ignorer                                   `yaml:"-"`
+	reloads chan struct{}                              `yaml:"-"`
+	restart context.CancelFunc                         `yaml:"-"`
+	jobsMu  sync.RWMutex                               `yaml:"-"`
 }
 
 // Config options for file vai.yml
@@ -32,6 +46,12 @@ type Config struct {
 	Cooldown         time.Duration `yaml:"cooldown,omitempty"`
 	BufferSize       int           `yaml:"bufferSize,omitempty"`
 	BatchingDuration time.Duration `yaml:"batchingDuration,omitempty"`
+	DisableHashCheck bool          `yaml:"disableHashCheck,omitempty"`
+	MaxSelfRestarts  int           `yaml:"maxSelfRestarts,omitempty"`
+	RespectGitignore *bool         `yaml:"respectGitignore,omitempty"`
+	Watcher          string        `yaml:"watcher,omitempty"`
+	PollInterval     time.Duration `yaml:"pollInterval,omitempty"`
+	PollHash         bool          `yaml:"pollHash,omitempty"`
 	serverityLevel   fswatcher.Severity
 }
 
@@ -45,10 +65,12 @@ func newVai(args *Args) (*Vai, error) {
 
 	v := &Vai{
 		cwd:     cwd,
+		args:    args,
 		manager: newManager(),
+		guard:   newLoopGuard(),
 	}
 
-	hasCLI := len(args.CmdFlags) > 0 || len(args.PositionalArgs) > 0
+	hasCLI := args.hasCmd()
 	hasConfig := fileExists(args.ConfigFile)
 
 	// Nothing provided, show help
@@ -56,22 +78,54 @@ func newVai(args *Args) (*Vai, error) {
 		return nil, fmt.Errorf("No config file and no command provided")
 	}
 
-	// Load config file as base if exist
 	if hasConfig {
-		logger.log(SeverityDebug, OpInfo, "Loading config from %s", args.ConfigFile)
-		v.applyConfig(args.ConfigFile)
+		v.config, _ = filepath.Abs(args.ConfigFile)
+	}
+	if err := v.load(); err != nil {
+		return nil, err
+	}
+	if hasConfig {
 		logger.log(SeverityInfo, OpSuccess, "Using config %s", cyan(args.ConfigFile))
 	}
 
+	return v, nil
+}
+
+// load builds the jobs from the config file and the CLI arguments
+func (v *Vai) load() error {
+	// Load config file as base if exist
+	if v.config != "" {
+		logger.log(SeverityDebug, OpInfo, "Loading config from %s", v.config)
+		errs, warnings := splitWarnings(validateFile(v.config))
+		for _, w := range warnings {
+			logger.log(SeverityWarn, OpWarn, "%v", w)
+		}
+		if len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+		if err := v.applyConfig(v.config); err != nil {
+			return fmt.Errorf("Failed to load config file: %v", err)
+		}
+	}
+
 	// Override config or CLI mode
-	if hasCLI {
-		v.applyCLI(args)
+	if v.args != nil && v.args.hasCmd() {
+		v.applyCLI(v.args)
 	}
 
 	// Set defaults
 	v.setDefaults()
 
-	return v, nil
+	// Compile trigger patterns
+	return v.compileMatchers()
+}
+
+// runsOnStart reports whether a job runs without waiting for a change
+func (v *Vai) runsOnStart(job Job) bool {
+	if job.RunOnStart == ChangeOnly {
+		return false
+	}
+	return v.args == nil || !v.args.NoInitialRun || job.RunOnStart == StartOnly
 }
 
 // startJobs starts all defined jobs
@@ -82,16 +136,15 @@ func (v *Vai) startJobs() {
 	}
 
 	logger.log(SeverityInfo, OpSuccess, "Jobs successfully imported: %s%s%s", ColorGreen, strings.Join(jobNames, ", "), ColorReset)
-	// Run jobs on startup
+	// Run jobs on startup, --no-initial-run keeps the ones running on start only
 	logger.log(SeverityInfo, OpWarn, "Running jobs...")
 	for jobName, job := range v.Jobs {
+		if !v.runsOnStart(job) {
+			logger.log(SeverityInfo, OpInfo, "Skipping initial run of job: %s%s%s", ColorGreen, jobName, ColorReset)
+			continue
+		}
 		logger.log(SeverityInfo, OpWarn, "Triggering job: %s%s%s", ColorGreen, jobName, ColorReset)
-		jobCtx, deregister := v.manager.register(jobName)
-		job.Name = jobName
-		go func(j Job) {
-			defer deregister()
-			j.start(jobCtx)
-		}(job)
+		v.trigger(jobName, job, nil)
 	}
 }
 
@@ -116,6 +169,36 @@ func (v *Vai) setDefaults() {
 		logger.log(SeverityDebug, OpInfo, "Setting default cooldown to %s", (100 * time.Millisecond).String())
 		v.Config.Cooldown = 100 * time.Millisecond
 	}
+	if v.Config.RespectGitignore == nil {
+		logger.log(SeverityDebug, OpInfo, "Setting default respect gitignore to %t", true)
+		respect := true
+		v.Config.RespectGitignore = &respect
+	}
+	if v.Config.Watcher == "" {
+		logger.log(SeverityDebug, OpInfo, "Setting default watcher to %s", "auto")
+		v.Config.Watcher = "auto"
+	}
+	if v.Config.PollInterval == 0 {
+		logger.log(SeverityDebug, OpInfo, "Setting default poll interval to %s", (500 * time.Millisecond).String())
+		v.Config.PollInterval = 500 * time.Millisecond
+	}
+	if v.Config.MaxSelfRestarts == 0 {
+		logger.log(SeverityDebug, OpInfo, "Setting default max self restarts to %d", 5)
+		v.Config.MaxSelfRestarts = 5
+	}
+}
+
+// compileMatchers compiles the trigger paths and patterns of every job once
+func (v *Vai) compileMatchers() error {
+	for name, job := range v.Jobs {
+		if job.Trigger == nil {
+			continue
+		}
+		if err := job.Trigger.compile(); err != nil {
+			return fmt.Errorf("job '%s': %v", name, err)
+		}
+	}
+	return nil
 }
 
 // applyCLI applies configuration from command line arguments
@@ -125,7 +208,12 @@ func (v *Vai) applyCLI(args *Args) {
 		return
 	}
 
+	globs := parseGlob(args.Glob)
 	patterns := parseRegex(args.Regex)
+	if args.Regex == "" && len(globs) > 0 {
+		// Globs replace the default regex patterns
+		patterns = nil
+	}
 	env := parseEnv(args.Env)
 	path := parsePath(args.Path)
 
@@ -146,10 +234,11 @@ func (v *Vai) applyCLI(args *Args) {
 	}
 
 	// CLI explicitly sets trigger
-	if path != "" || len(patterns) > 0 {
+	if path != "" || len(patterns) > 0 || len(globs) > 0 {
 		job.Trigger = &Trigger{
 			Paths: []string{path},
 			Regex: patterns,
+			Glob:  globs,
 		}
 	}
 
@@ -166,11 +255,10 @@ func (v *Vai) applyCLI(args *Args) {
 }
 
 // applyConfig loads configuration from a file
-func (v *Vai) applyConfig(path string) {
+func (v *Vai) applyConfig(path string) error {
 	cfg, err := fromFile(path)
 	if err != nil {
-		logger.log(SeverityError, OpError, "Failed to load config file: %v", err)
-		os.Exit(1)
+		return err
 	}
 
 	// Save runtime
@@ -182,74 +270,151 @@ func (v *Vai) applyConfig(path string) {
 
 	// Restore runtime
 	v.cwd = cwd
+	return nil
+}
+
+// job returns the current definition of a job, safe to call from timers while a reload swaps the jobs
+func (v *Vai) job(name string) (Job, bool) {
+	v.jobsMu.RLock()
+	defer v.jobsMu.RUnlock()
+	job, ok := v.Jobs[name]
+	return job, ok
+}
+
+// dispatch checks an event and triggers the ones that match, unchanged content only triggers jobs watching chmod
+func (v *Vai) dispatch(event fswatcher.WatchEvent, unchanged bool) {
+	eventPath := event.Path
+	for _, jobName := range v.matchJobs(event) {
+		job := v.Jobs[jobName]
+		if unchanged && !watchesChmod(job) {
+			logger.log(SeverityDebug, OpWarn, "Skipping job '%s': content of '%s' unchanged since last dispatch", jobName, eventPath)
+			continue
+		}
+
+		// Guard against jobs re-triggering themselves
+		if !v.allowTrigger(jobName, job, []string{eventPath}) {
+			continue
+		}
 
-	if v.Config.Severity != "" {
-		logger = newLogger(parseSeverity(v.Config.Severity))
+		// Job is a match, batch it if it has its own window
+		if job.Trigger.Batch > 0 || job.Trigger.Cooldown > 0 {
+			v.enqueue(jobName, job, eventPath)
+			continue
+		}
+		v.trigger(jobName, job, []string{eventPath})
 	}
 }
 
-// dispatch checks an event and triggers the ones that match
-func (v *Vai) dispatch(eventPath string) {
+// matchJobs returns the jobs whose trigger matches an event
+func (v *Vai) matchJobs(event fswatcher.WatchEvent) []string {
 	if len(v.Jobs) == 0 {
 		logger.log(SeverityError, OpError, "No jobs to dispatch event to")
-		return
+		return nil
 	}
 
+	absEventPath, canonicalEventPath := resolvePath(event.Path)
+
+	var names []string
 	for jobName, job := range v.Jobs {
-		if job.Trigger == nil || len(job.Trigger.Paths) == 0 {
-			logger.log(SeverityWarn, OpError, "Skipping job '%s': no paths defined", jobName)
+		m := matchJob(job, event, absEventPath, canonicalEventPath)
+		if !m.ok {
+			logger.log(m.severity, m.op, "Skipping job '%s': %s", jobName, m.reason)
 			continue
 		}
+		names = append(names, jobName)
+	}
+	return names
+}
 
-		// Check if the event path is in job's vai paths
-		pathMatch := false
-		absEventPath, _ := filepath.Abs(eventPath)
-		canonicalEventPath, _ := filepath.EvalSymlinks(absEventPath)
-		if canonicalEventPath == "" {
-			canonicalEventPath = absEventPath
+// jobMatch is the outcome of matching an event against a job trigger
+type jobMatch struct {
+	ok       bool
+	kind     string
+	relPath  string
+	pattern  string
+	reason   string
+	severity Severity
+	op       Op
+}
+
+// matchJob checks an event against a job trigger and explains why it was skipped
+func matchJob(job Job, event fswatcher.WatchEvent, absEventPath, canonicalEventPath string) jobMatch {
+	if job.RunOnStart == StartOnly {
+		return jobMatch{kind: "start only", reason: "runs on start only", severity: SeverityDebug, op: OpWarn}
+	}
+	if job.Trigger == nil || len(job.Trigger.Paths) == 0 {
+		return jobMatch{kind: "no trigger", reason: "no paths defined", severity: SeverityWarn, op: OpError}
+	}
+	if job.Trigger.matcher == nil {
+		if err := job.Trigger.compile(); err != nil {
+			return jobMatch{kind: "invalid trigger", reason: err.Error(), severity: SeverityError, op: OpError}
 		}
+	}
 
-		for _, watchPath := range job.Trigger.Paths {
-			absWatchPath, _ := filepath.Abs(watchPath)
-			canonicalWatchPath, _ := filepath.EvalSymlinks(absWatchPath)
-			if canonicalWatchPath == "" {
-				canonicalWatchPath = absWatchPath
-			}
+	// Check if the event path is in job's vai paths
+	relPath, ok := job.Trigger.relPath(absEventPath, canonicalEventPath)
+	if !ok {
+		return jobMatch{kind: "path mismatch", reason: fmt.Sprintf("event path '%s' is not in watched paths %s", event.Path, strings.Join(job.Trigger.Paths, ", ")), severity: SeverityDebug, op: OpWarn}
+	}
 
-			if strings.HasPrefix(canonicalEventPath, canonicalWatchPath) {
-				pathMatch = true
-				break
-			}
-		}
+	// Check regex and glob patterns
+	p, ok := job.Trigger.matcher.decide(relPath)
+	m := jobMatch{relPath: relPath, severity: SeverityDebug, op: OpWarn}
+	if p != nil {
+		m.pattern = p.src
+	}
+	switch {
+	case !ok && p != nil:
+		m.kind, m.reason = "excluded", fmt.Sprintf("event path '%s' is excluded by '%s'", relPath, p.src)
+		return m
+	case !ok:
+		m.kind, m.reason = "no inclusion match", fmt.Sprintf("event path '%s' does not match patterns", relPath)
+		return m
+	}
 
-		if !pathMatch {
-			logger.log(SeverityDebug, OpWarn, "Skipping job '%s': event path '%s' is not in watched paths", jobName, eventPath)
+	// Check event types
+	if !matchEvents(event.Types, job.Trigger.Events) {
+		m.kind, m.reason = "event mismatch", fmt.Sprintf("event %v on '%s' is not in trigger events", event.Types, event.Path)
+		return m
+	}
+	m.ok = true
+	return m
+}
+
+// dispatchHeld triggers each job once with all the files changed during a git operation
+func (v *Vai) dispatchHeld(events []fswatcher.WatchEvent) {
+	files := make(map[string][]string)
+	seen := make(map[string]struct{})
+	for _, event := range events {
+		if _, ok := seen[event.Path]; ok {
 			continue
 		}
+		seen[event.Path] = struct{}{}
+		unchanged := !v.Config.DisableHashCheck && v.unchanged(event.Path)
+		for _, jobName := range v.matchJobs(event) {
+			if unchanged && !watchesChmod(v.Jobs[jobName]) {
+				continue
+			}
+			files[jobName] = append(files[jobName], event.Path)
+		}
+	}
 
-		// Check regex
-		if !matchRegex(eventPath, job.Trigger.Regex) {
-			logger.log(SeverityDebug, OpWarn, "Skipping job '%s': event path '%s' does not match regex", jobName, eventPath)
+	for jobName, changed := range files {
+		job := v.Jobs[jobName]
+		if !v.allowTrigger(jobName, job, changed) {
 			continue
 		}
-
-		// Job is a match
-		logger.log(SeverityDebug, OpSuccess, "Triggering job: %s", green("[", jobName, "]"))
-
-		go func(name string, j Job) {
-			// Register the job
-			ctx, deregister := v.manager.register(name)
-			j.Name = name
-
-			defer deregister() // Deregister on complete
-			j.start(ctx)
-		}(jobName, job)
+		v.trigger(jobName, job, changed)
 	}
 }
 
-// save writes the Vai configuration to a YAML file
-func (v *Vai) save(filePath string) error {
+// save writes the Vai configuration to a YAML file, with optional comment lines at the top
+func (v *Vai) save(filePath string, comments ...string) error {
 	var b bytes.Buffer
+	b.WriteString(schemaHeader)
+	for _, c := range comments {
+		b.WriteString("# " + c + "\n")
+	}
 	encoder := yaml.NewEncoder(&b)
 	encoder.SetIndent(2)
 	err := encoder.Encode(v)
@@ -261,25 +426,68 @@ func (v *Vai) save(filePath string) error {
 
 // startWatch dispatches events to the appropriate jobs
 func (v *Vai) startWatch(ctx context.Context) {
-	var err error
-
 	v.startJobs()
 
-	v.fswatcher, err = v.newWatcher()
-	if err != nil {
-		logger.log(SeverityError, OpError, "Failed to create watcher: %v", err)
-		return
+	// Reload the jobs when the config file changes
+	if v.config != "" {
+		v.reloads = make(chan struct{}, 1)
+		go v.watchConfig(ctx)
 	}
 
-	// Start the event listener
-	go v.runEventLoop(ctx)
+	for v.watch(ctx) {
+		logger.log(SeverityInfo, OpInfo, "Restarting watcher with the new config")
+	}
+}
+
+// watch runs the watcher until the context is canceled, it reports whether a reload asked for a restart
+func (v *Vai) watch(ctx context.Context) bool {
+	var err error
+
+	// Collect unique paths from all jobs
+	pathsToWatch := v.watchPaths()
+
+	// Load ignore files before the watcher so it can filter ignored directories
+	v.ignore = loadIgnorer(pathsToWatch, v.Config.RespectGitignore == nil || *v.Config.RespectGitignore)
+	v.git = newGitGuard(pathsToWatch)
+	v.seedHashes(pathsToWatch)
+
+	if v.source == nil {
+		v.source, err = v.newSource()
+		if err != nil {
+			logger.log(SeverityError, OpError, "Failed to create watcher: %v", err)
+			return false
+		}
+	}
+
+	watchCtx, restart := context.WithCancel(ctx)
+	defer restart()
+	v.restart = restart
+
+	// Start the event listener and keep the trigger paths watched
+	done := make(chan struct{})
+	go func() {
+		v.runEventLoop(watchCtx)
+		close(done)
+	}()
+	go newPathSupervisor(v.source, pathsToWatch).run(watchCtx)
 
 	// Start watching
-	if err := v.fswatcher.Watch(ctx); err != nil {
+	if err := v.source.Watch(watchCtx); err != nil {
 		logger.log(SeverityError, OpError, "Failed to start vai: %v", err)
+		return false
 	}
+	restarting := ctx.Err() == nil && watchCtx.Err() != nil
+	restart()
+	<-done
 
-	// Collect unique paths from all jobs
+	if restarting {
+		v.source = nil
+	}
+	return restarting
+}
+
+// watchPaths collects the unique trigger paths of all jobs
+func (v *Vai) watchPaths() []string {
 	pathsToWatch := make(map[string]struct{})
 	for _, job := range v.Jobs {
 		if job.Trigger != nil {
@@ -294,14 +502,12 @@ func (v *Vai) startWatch(ctx context.Context) {
 		pathsToWatch[v.cwd] = struct{}{}
 	}
 
-	// Add all paths to the watcher
-	for path := range pathsToWatch {
-		if err := v.fswatcher.AddPath(path); err != nil {
-			if ctx.Err() == nil {
-				logger.log(SeverityError, OpError, "Failed to watch path %s: %v", path, err)
-			}
-		}
+	paths := make([]string, 0, len(pathsToWatch))
+	for p := range pathsToWatch {
+		paths = append(paths, p)
 	}
+	slices.Sort(paths)
+	return paths
 }
 
 // runEventLoop listens for file events and dispatches them
@@ -310,10 +516,32 @@ func (v *Vai) runEventLoop(ctx context.Context) {
 		select {
 		case <-ctx.Done():
 			return
-		case event, ok := <-v.fswatcher.Events():
+		case event, ok := <-v.source.Events():
 			if !ok {
 				return
 			}
+			if v.ignore.ignored(event.Path, isDir(event.Path)) {
+				logger.log(SeverityDebug, OpInfo, "Ignoring event for %s: path is ignored", event.Path)
+				continue
+			}
+			if v.isConfig(event.Path) {
+				logger.log(SeverityDebug, OpInfo, "Ignoring event for %s: config file", event.Path)
+				continue
+			}
+			if v.git.hold(event) {
+				logger.log(SeverityDebug, OpInfo, "Holding event for %s: git operation in progress", event.Path)
+				continue
+			}
+			if v.git.inGitDir(event.Path) {
+				logger.log(SeverityDebug, OpInfo, "Ignoring event for %s: inside git directory", event.Path)
+				continue
+			}
+			// Metadata-only changes keep the content, they can only trigger jobs watching chmod
+			unchanged := !v.Config.DisableHashCheck && v.unchanged(event.Path)
+			if unchanged && !v.watchesChmod() {
+				logger.log(SeverityDebug, OpInfo, "Ignoring event for %s: content unchanged since last dispatch", event.Path)
+				continue
+			}
 			if v.Config.ClearCli {
 				// Clear the console before displaying the change
 				clearCLI()
@@ -329,8 +557,12 @@ func (v *Vai) runEventLoop(ctx context.Context) {
 
 			logger.log(SeverityWarn, OpTrigger, "%s", purple(fmt.Sprintf("Change detected: %s", displayPath)))
 			// Dispatch the event
-			v.dispatch(event.Path)
-		case err, ok := <-v.fswatcher.Dropped():
+			v.dispatch(event, unchanged)
+		case <-v.reloads:
+			v.reload()
+		case events := <-v.git.releases():
+			v.dispatchHeld(events)
+		case err, ok := <-v.source.Errors():
 			if !ok {
 				return
 			}
@@ -339,10 +571,105 @@ func (v *Vai) runEventLoop(ctx context.Context) {
 	}
 }
 
-// newWatcher sets up the file watcher
-func (v *Vai) newWatcher() (fswatcher.Watcher, error) {
-	// Aggregate regex patterns
-	incRegex, excRegex := v.aggregateRegex()
+// unchanged reports whether a file has the same content as when it was last dispatched
+func (v *Vai) unchanged(path string) bool {
+	if v.hashes == nil {
+		v.hashes = make(map[string]string)
+	}
+
+	hash, ok := contentHash(path)
+	if !ok {
+		delete(v.hashes, path)
+		return false
+	}
+	if last, ok := v.hashes[path]; ok && last == hash {
+		return true
+	}
+	v.hashes[path] = hash
+	return false
+}
+
+// maxHashSize is the largest file whose content is compared, bigger files always trigger
+const maxHashSize = 64 << 20
+
+// maxSeededFiles bounds the files hashed at startup
+const maxSeededFiles = 20000
+
+// contentHash hashes a regular file small enough to compare
+func contentHash(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxHashSize {
+		return "", false
+	}
+	hash := hashFile(path)
+	return hash, hash != ""
+}
+
+// seedHashes hashes the files matching a job so their first identical rewrite is suppressed too
+func (v *Vai) seedHashes(roots []string) {
+	if v.Config.DisableHashCheck {
+		return
+	}
+	if v.hashes == nil {
+		v.hashes = make(map[string]string)
+	}
+
+	seeded := 0
+	for _, root := range roots {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if d.Name() == ".git" || path != root && v.ignore.ignored(path, true) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if seeded >= maxSeededFiles {
+				return filepath.SkipAll
+			}
+			if _, ok := v.hashes[path]; ok || v.ignore.ignored(path, false) || !v.matchesAnyJob(path) {
+				return nil
+			}
+			if hash, ok := contentHash(path); ok {
+				v.hashes[path] = hash
+				seeded++
+			}
+			return nil
+		})
+	}
+	logger.log(SeverityDebug, OpInfo, "Seeded content hashes of %d files", seeded)
+}
+
+// matchesAnyJob reports whether a change of the file would trigger a job
+func (v *Vai) matchesAnyJob(path string) bool {
+	abs, canonical := resolvePath(path)
+	event := fswatcher.WatchEvent{Path: path, Types: []fswatcher.EventType{fswatcher.EventMod}}
+	for _, job := range v.Jobs {
+		if matchJob(job, event, abs, canonical).ok {
+			return true
+		}
+	}
+	return false
+}
+
+// watcherExclusions returns the patterns the watcher filters on absolute paths, only ignored directories.
+// Trigger exclusions are relative to each trigger path, so only vai can evaluate them
+func (v *Vai) watcherExclusions() []string {
+	return v.ignore.ignoredDirRegex()
+}
+
+// newSource sets up the file watcher
+func (v *Vai) newSource() (eventSource, error) {
+	excRegex := v.watcherExclusions()
+
+	// Create a polling watcher where native events aren't delivered
+	if v.usePolling() {
+		return newPoller(v.Config.PollInterval, v.Config.PollHash, v.Config.BufferSize, excRegex, func(path string) bool {
+			return v.ignore.ignored(path, true)
+		})
+	}
 
 	// Create a fswatcher instance
 	opts := []fswatcher.WatcherOpt{
@@ -352,8 +679,12 @@ func (v *Vai) newWatcher() (fswatcher.Watcher, error) {
 	if v.Config.BatchingDuration > 0 {
 		opts = append(opts, fswatcher.WithEventBatching(v.Config.BatchingDuration))
 	}
-	if len(incRegex) > 0 {
-		opts = append(opts, fswatcher.WithIncRegex(incRegex...))
+	// Paths can only be added at runtime once watching, missing ones are added by the supervisor.
+	// Nested paths are covered by their parent, adding them too races with its recursive watch
+	for _, path := range outermost(v.watchPaths()) {
+		if isDir(path) {
+			opts = append(opts, fswatcher.WithPath(path))
+		}
 	}
 	if len(excRegex) > 0 {
 		opts = append(opts, fswatcher.WithExcRegex(excRegex...))
@@ -363,37 +694,44 @@ func (v *Vai) newWatcher() (fswatcher.Watcher, error) {
 		opts = append(opts, fswatcher.WithLogFile("debug.log"))
 	}
 
-	return fswatcher.New(opts...)
+	return newFswatcherSource(opts...)
 }
 
-// aggregateRegex collects all unique regex patterns from all jobs
-func (v *Vai) aggregateRegex() (incPatterns, excPatterns []string) {
-	incRegexMap := make(map[string]struct{})
-	excRegexMap := make(map[string]struct{})
-
-	for _, job := range v.Jobs {
-		if job.Trigger != nil {
-			for _, rx := range job.Trigger.Regex {
-				if trimmedRx, found := strings.CutPrefix(rx, "!"); found {
-					excRegexMap[trimmedRx] = struct{}{}
-				} else {
-					incRegexMap[rx] = struct{}{}
-				}
-			}
+// outermost returns the absolute paths that aren't inside another one
+func outermost(paths []string) []string {
+	abs := make([]string, 0, len(paths))
+	for _, p := range paths {
+		a, _ := resolvePath(p)
+		abs = append(abs, a)
+	}
+	var roots []string
+	for _, a := range abs {
+		nested := slices.ContainsFunc(abs, func(parent string) bool {
+			rel, ok := within(parent, a)
+			return ok && rel != "."
+		})
+		if !nested && !slices.Contains(roots, a) {
+			roots = append(roots, a)
 		}
 	}
+	return roots
+}
 
-	incPatterns = make([]string, 0, len(incRegexMap))
-	for rx := range incRegexMap {
-		incPatterns = append(incPatterns, rx)
+// usePolling checks if the polling watcher is configured or needed by the watched filesystems
+func (v *Vai) usePolling() bool {
+	switch v.Config.Watcher {
+	case "poll":
+		return true
+	case "native":
+		return false
 	}
-
-	excPatterns = make([]string, 0, len(excRegexMap))
-	for rx := range excRegexMap {
-		excPatterns = append(excPatterns, rx)
+	for _, path := range v.watchPaths() {
+		if fsType, ok := remoteFS(path); ok {
+			logger.log(SeverityWarn, OpWarn, "Path %s is on a %s filesystem, using the polling watcher (set watcher: native to keep native events)", path, fsType)
+			return true
+		}
 	}
-
-	return incPatterns, excPatterns
+	return false
 }
 
 // clearCLI clears the cli
@@ -416,6 +754,12 @@ func fileExists(filename string) bool {
 	return !info.IsDir()
 }
 
+// isDir checks if a path exists and is a dir
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 // parsePath parses the path to watch
 func parsePath(pathFlag string) string {
 	if pathFlag != "" {
@@ -428,16 +772,20 @@ func parsePath(pathFlag string) string {
 // parseRegex determines the file patterns to watch
 func parseRegex(regexFlag string) []string {
 	if regexFlag != "" {
-		patterns := strings.Split(regexFlag, ",")
-		for i, p := range patterns {
-			patterns[i] = strings.TrimSpace(p)
-		}
-		return patterns
+		return splitPatterns(regexFlag)
 	}
 	// Default patterns
 	return []string{".*\\.go$", "^go\\.mod$", "^go\\.sum$"}
 }
 
+// parseGlob determines the glob patterns to watch
+func parseGlob(globFlag string) []string {
+	if globFlag == "" {
+		return nil
+	}
+	return splitPatterns(globFlag)
+}
+
 // fromFile loads a Workflow from a YAML configuration file
 func fromFile(filePath string) (*Vai, error) {
 	data, err := os.ReadFile(filePath)
@@ -448,9 +796,21 @@ func fromFile(filePath string) (*Vai, error) {
 	if err := yaml.Unmarshal(data, &vai); err != nil {
 		return nil, err
 	}
+	switch vai.Config.Watcher {
+	case "", "auto", "native", "poll":
+	default:
+		return nil, fmt.Errorf("unknown watcher '%s', use auto, native or poll", vai.Config.Watcher)
+	}
 	for name, job := range vai.Jobs {
 		job.Name = name
 		vai.Jobs[name] = job
+		if job.Trigger != nil {
+			for _, e := range job.Trigger.Events {
+				if _, ok := eventTypes[strings.ToLower(e)]; !ok {
+					return nil, fmt.Errorf("job '%s': unknown trigger event '%s'", name, e)
+				}
+			}
+		}
 	}
 	return &vai, nil
 }
@@ -470,40 +830,51 @@ func parseEnv(envFlag string) map[string]string {
 	return envMap
 }
 
-// matchRegex checks if the file matches the regex patterns
-func matchRegex(path string, regex []string) bool {
-	if len(regex) == 0 {
-		return true
-	}
-
-	included := false
-	hasInclusion := false
+// eventTypes maps trigger event names to watcher event types
+var eventTypes = map[string]fswatcher.EventType{
+	"create": fswatcher.EventCreate,
+	"write":  fswatcher.EventMod,
+	"remove": fswatcher.EventRemove,
+	"rename": fswatcher.EventRename,
+	"chmod":  fswatcher.EventChmod,
+}
 
-	for _, rx := range regex {
-		// Check for exclusion
-		if strings.HasPrefix(rx, "!") {
-			pattern := strings.TrimPrefix(rx, "!")
-			matched, err := regexp.MatchString(pattern, path)
-			if err == nil && matched {
-				return false
-			}
-			continue
-		}
+// watchesChmod reports whether a job trigger filters on chmod events
+func watchesChmod(job Job) bool {
+	if job.Trigger == nil {
+		return false
+	}
+	return slices.ContainsFunc(job.Trigger.Events, func(name string) bool {
+		return strings.EqualFold(name, "chmod")
+	})
+}
 
-		// Check for inclusion
-		hasInclusion = true
-		matched, err := regexp.MatchString(rx, path)
-		if err == nil && matched {
-			included = true
+// watchesChmod reports whether any job filters on chmod events
+func (v *Vai) watchesChmod() bool {
+	for _, job := range v.Jobs {
+		if watchesChmod(job) {
+			return true
 		}
 	}
+	return false
+}
 
-	// If no inclusion patterns are defined, we default to including (unless excluded above)
-	if !hasInclusion {
+// matchEvents checks if any of the event types is in the trigger events
+func matchEvents(types []fswatcher.EventType, events []string) bool {
+	if len(events) == 0 {
 		return true
 	}
 
-	return included
+	for _, name := range events {
+		want, ok := eventTypes[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		if slices.Contains(types, want) {
+			return true
+		}
+	}
+	return false
 }
 
 // parseFlags determines the commands to run and the flags to use
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
-	"sort"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sgtdi/fswatcher"
 	"gopkg.in/yaml.v3"
 )
 
@@ -26,6 +30,9 @@ func TestWatch_SetDefaults(t *testing.T) {
 		if w.Config.Cooldown != 100*time.Millisecond {
 			t.Errorf("Expected Cooldown to be 100ms, got %v", w.Config.Cooldown)
 		}
+		if w.Config.RespectGitignore == nil || !*w.Config.RespectGitignore {
+			t.Error("Expected RespectGitignore to default to true")
+		}
 	})
 
 	t.Run("does not override existing values", func(t *testing.T) {
@@ -71,6 +78,10 @@ func TestWatch_Save(t *testing.T) {
 		t.Fatalf("Failed to read saved file: %v", err)
 	}
 
+	if !strings.HasPrefix(string(data), schemaHeader) {
+		t.Errorf("Expected the saved file to start with the schema header, got %q", data)
+	}
+
 	var loadedVai Vai
 	if err := yaml.Unmarshal(data, &loadedVai); err != nil {
 		t.Fatalf("Failed to unmarshal saved data: %v", err)
@@ -84,41 +95,43 @@ func TestWatch_Save(t *testing.T) {
 	}
 }
 
-func TestAggregateRegex(t *testing.T) {
-	vai := &Vai{
+func TestWatcherExclusions(t *testing.T) {
+	// The project lives under a directory matching a trigger exclusion
+	root := filepath.Join(t.TempDir(), "tmp", "project")
+	os.MkdirAll(filepath.Join(root, "tmp"), 0755)
+
+	v := &Vai{
+		cwd: root,
 		Jobs: map[string]Job{
-			"job1": {
-				Trigger: &Trigger{Regex: []string{`\.go$`, `!\.test\.go$`, `\.mod$`}},
-			},
-			"job2": {
-				Trigger: &Trigger{Regex: []string{`\.html$`, `!\.test\.go$`}},
-			},
-			"job3": {},
-			"job4": {
-				Trigger: &Trigger{Regex: []string{`\.go$`}},
-			},
+			"build": {Trigger: &Trigger{Paths: []string{root}, Regex: []string{`\.go$`, `!tmp/`, `!^vendor/`}}},
 		},
 	}
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	v.ignore = loadIgnorer([]string{root}, true)
 
-	inc, exc := vai.aggregateRegex()
-
-	sort.Strings(inc)
-	sort.Strings(exc)
-
-	expectedInc := []string{`\.go$`, `\.html$`, `\.mod$`}
-	sort.Strings(expectedInc)
-	expectedExc := []string{`\.test\.go$`}
-	sort.Strings(expectedExc)
+	main := filepath.Join(root, "main.go")
+	for _, rx := range v.watcherExclusions() {
+		if regexp.MustCompile(rx).MatchString(main) {
+			t.Errorf("Expected the watcher not to exclude %s with %s", main, rx)
+		}
+	}
 
-	if !reflect.DeepEqual(inc, expectedInc) {
-		t.Errorf("Expected inclusion patterns %v, got %v", expectedInc, inc)
+	event := func(path string) fswatcher.WatchEvent {
+		return fswatcher.WatchEvent{Path: path, Types: []fswatcher.EventType{fswatcher.EventMod}}
+	}
+	if names := v.matchJobs(event(main)); len(names) != 1 {
+		t.Errorf("Expected main.go to trigger the job, got %v", names)
 	}
-	if !reflect.DeepEqual(exc, expectedExc) {
-		t.Errorf("Expected exclusion patterns %v, got %v", expectedExc, exc)
+	for _, excluded := range []string{filepath.Join(root, "tmp", "x.go"), filepath.Join(root, "vendor", "x.go")} {
+		if names := v.matchJobs(event(excluded)); len(names) != 0 {
+			t.Errorf("Expected %s to be excluded relative to the trigger path, got %v", excluded, names)
+		}
 	}
 }
 
-func TestMatchRegex(t *testing.T) {
+func TestMatcher_Regex(t *testing.T) {
 	testCases := []struct {
 		name     string
 		path     string
@@ -171,9 +184,38 @@ func TestMatchRegex(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result := matchRegex(tc.path, tc.patterns)
+			m, err := newMatcher(tc.patterns, nil)
+			if err != nil {
+				t.Fatalf("newMatcher failed: %v", err)
+			}
+			result := m.match(tc.path)
 			if result != tc.expected {
-				t.Errorf("matchRegex(%q, %v) = %v, want %v", tc.path, tc.patterns, result, tc.expected)
+				t.Errorf("match(%q, %v) = %v, want %v", tc.path, tc.patterns, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMatchEvents(t *testing.T) {
+	testCases := []struct {
+		name     string
+		types    []fswatcher.EventType
+		events   []string
+		expected bool
+	}{
+		{"No events match everything", []fswatcher.EventType{fswatcher.EventChmod}, nil, true},
+		{"Matches create", []fswatcher.EventType{fswatcher.EventCreate}, []string{"create"}, true},
+		{"Write maps to modify", []fswatcher.EventType{fswatcher.EventMod}, []string{"write"}, true},
+		{"Ignores chmod", []fswatcher.EventType{fswatcher.EventChmod}, []string{"create", "write"}, false},
+		{"Matches any merged type", []fswatcher.EventType{fswatcher.EventMod, fswatcher.EventRename}, []string{"rename"}, true},
+		{"Case insensitive", []fswatcher.EventType{fswatcher.EventRemove}, []string{"Remove"}, true},
+		{"Unknown types do not match", nil, []string{"create"}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if result := matchEvents(tc.types, tc.events); result != tc.expected {
+				t.Errorf("matchEvents(%v, %v) = %v, want %v", tc.types, tc.events, result, tc.expected)
 			}
 		})
 	}
@@ -306,3 +348,151 @@ func TestClearCLI(t *testing.T) {
 		}
 	})
 }
+
+func TestUnchanged(t *testing.T) {
+	v := &Vai{}
+	file := filepath.Join(t.TempDir(), "main.go")
+	os.WriteFile(file, []byte("package main"), 0644)
+
+	if v.unchanged(file) {
+		t.Error("Expected the first event for a file to be dispatched")
+	}
+	// Rewriting identical content is suppressed
+	os.WriteFile(file, []byte("package main"), 0644)
+	if !v.unchanged(file) {
+		t.Error("Expected an identical rewrite to be suppressed")
+	}
+	os.WriteFile(file, []byte("package main\n"), 0644)
+	if v.unchanged(file) {
+		t.Error("Expected a content change to be dispatched")
+	}
+	os.Remove(file)
+	if v.unchanged(file) {
+		t.Error("Expected a removal to be dispatched")
+	}
+	os.WriteFile(file, []byte("package main\n"), 0644)
+	if v.unchanged(file) {
+		t.Error("Expected a recreated file to be dispatched")
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	logger = newLogger(SeverityError)
+	root := b.TempDir()
+
+	// Many jobs on the same tree, none matching the events
+	v := &Vai{Jobs: map[string]Job{}, manager: newManager()}
+	for i := range 50 {
+		v.Jobs[fmt.Sprintf("job%d", i)] = Job{
+			Trigger: &Trigger{
+				Paths: []string{root},
+				Regex: []string{fmt.Sprintf(`\.ext%d$`, i), `!vendor/`, `!node_modules/`},
+				Glob:  []string{fmt.Sprintf("**/*.glob%d", i)},
+			},
+		}
+	}
+	if err := v.compileMatchers(); err != nil {
+		b.Fatal(err)
+	}
+
+	paths := make([]string, 1000)
+	for i := range paths {
+		paths[i] = filepath.Join(root, fmt.Sprintf("pkg%d", i%40), fmt.Sprintf("sub%d", i%7), fmt.Sprintf("file%d.go", i))
+	}
+
+	b.ResetTimer()
+	for i := range b.N {
+		v.dispatch(fswatcher.WatchEvent{Path: paths[i%len(paths)], Types: []fswatcher.EventType{fswatcher.EventMod}}, false)
+	}
+}
+
+func TestStartJobs_RunOnStart(t *testing.T) {
+	resetGlobals()
+	var jobs Vai
+	err := yaml.Unmarshal([]byte(`jobs:
+  app:
+    cmd: go run .
+    trigger:
+      glob: ["**/*.go"]
+  test:
+    cmd: go test ./...
+    runOnStart: false
+    trigger:
+      glob: ["**/*.go"]
+  setup:
+    cmd: go mod download
+    runOnStart: only
+    trigger:
+      glob: ["**/*.go"]
+`), &jobs)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if jobs.Jobs["app"].RunOnStart != StartAndChange || jobs.Jobs["test"].RunOnStart != ChangeOnly || jobs.Jobs["setup"].RunOnStart != StartOnly {
+		t.Fatalf("Unexpected start modes %+v", jobs.Jobs)
+	}
+
+	started := func(args *Args) []string {
+		var names []string
+		v := &Vai{cwd: t.TempDir(), args: args, Jobs: jobs.Jobs}
+		v.launch = func(name string, _ Job, _ []string) {
+			names = append(names, name)
+		}
+		v.startJobs()
+		slices.Sort(names)
+		return names
+	}
+	if names := started(&Args{}); !reflect.DeepEqual(names, []string{"app", "setup"}) {
+		t.Errorf("Expected app and setup to run on start, got %v", names)
+	}
+	if names := started(&Args{NoInitialRun: true}); !reflect.DeepEqual(names, []string{"setup"}) {
+		t.Errorf("Expected only setup to run with --no-initial-run, got %v", names)
+	}
+
+	// Jobs running on start only never fire on change
+	v := &Vai{cwd: t.TempDir(), Jobs: jobs.Jobs}
+	v.setDefaults()
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	names := v.matchJobs(fswatcher.WatchEvent{Path: filepath.Join(v.cwd, "main.go"), Types: []fswatcher.EventType{fswatcher.EventMod}})
+	slices.Sort(names)
+	if !reflect.DeepEqual(names, []string{"app", "test"}) {
+		t.Errorf("Expected app and test to fire on change, got %v", names)
+	}
+
+	// The default mode is not written back
+	data, _ := yaml.Marshal(jobs.Jobs["app"])
+	if strings.Contains(string(data), "runOnStart") {
+		t.Errorf("Expected the default mode to be omitted, got:\n%s", data)
+	}
+	data, _ = yaml.Marshal(jobs.Jobs["setup"])
+	if !strings.Contains(string(data), "runOnStart: only") {
+		t.Errorf("Expected runOnStart: only, got:\n%s", data)
+	}
+}
+
+func TestSeedHashes(t *testing.T) {
+	root := t.TempDir()
+	main := filepath.Join(root, "main.go")
+	readme := filepath.Join(root, "README.md")
+	os.WriteFile(main, []byte("package main"), 0644)
+	os.WriteFile(readme, []byte("# app"), 0644)
+
+	v := &Vai{cwd: root, Jobs: map[string]Job{
+		"build": {Cmd: "go", Trigger: &Trigger{Paths: []string{root}, Glob: []string{"**/*.go"}}},
+	}}
+	if err := v.compileMatchers(); err != nil {
+		t.Fatalf("compileMatchers failed: %v", err)
+	}
+	v.seedHashes([]string{root})
+
+	// A formatter rewriting a watched file with identical content triggers nothing
+	os.WriteFile(main, []byte("package main"), 0644)
+	if !v.unchanged(main) {
+		t.Error("Expected the first identical rewrite of a seeded file to be suppressed")
+	}
+	if _, ok := v.hashes[readme]; ok {
+		t.Error("Expected files matching no job not to be hashed")
+	}
+}